@@ -0,0 +1,172 @@
+package benchmarks
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const output = `goos: linux
+goarch: amd64
+pkg: github.com/corentings/goTeaching/goroutines_merge_sort
+cpu: AMD Ryzen 9 5950X 16-Core Processor
+BenchmarkMergesort/500-32         	   28944	     41253 ns/op	   40960 B/op	     499 allocs/op
+BenchmarkMergesort/1000-32        	   13426	     88519 ns/op	   90112 B/op	     999 allocs/op
+PASS
+ok  	github.com/corentings/goTeaching/goroutines_merge_sort	3.102s
+goos: linux
+goarch: amd64
+pkg: github.com/corentings/goTeaching/goroutines_sum_square
+cpu: AMD Ryzen 9 5950X 16-Core Processor
+BenchmarkKernels/avx2/1000
+    kernel_test.go:74: selected kernel: avx2
+BenchmarkKernels/avx2/1000        	     100	       317.1 ns/op	25226.25 MB/s	        25.58 GB/s	       0 B/op	       0 allocs/op
+BenchmarkSkewedWorkload/static    	     100	     75183 ns/op
+PASS
+ok  	github.com/corentings/goTeaching/goroutines_sum_square	6.944s
+`
+
+func TestParse(t *testing.T) {
+	results, err := Parse(strings.NewReader(output))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	const cpu = "AMD Ryzen 9 5950X 16-Core Processor"
+	expected := []Result{
+		{Package: "github.com/corentings/goTeaching/goroutines_merge_sort", Name: "BenchmarkMergesort/500", Size: 500, Procs: 32,
+			Iterations: 28944, NsPerOp: 41253, BytesPerOp: 40960, AllocsPerOp: 499, GOOS: "linux", GOARCH: "amd64", CPU: cpu},
+		{Package: "github.com/corentings/goTeaching/goroutines_merge_sort", Name: "BenchmarkMergesort/1000", Size: 1000, Procs: 32,
+			Iterations: 13426, NsPerOp: 88519, BytesPerOp: 90112, AllocsPerOp: 999, GOOS: "linux", GOARCH: "amd64", CPU: cpu},
+		{Package: "github.com/corentings/goTeaching/goroutines_sum_square", Name: "BenchmarkKernels/avx2/1000", Size: 1000, Procs: 1,
+			Iterations: 100, NsPerOp: 317.1, MBPerSec: 25226.25, Metrics: map[string]float64{"GB/s": 25.58}, GOOS: "linux", GOARCH: "amd64", CPU: cpu},
+		{Package: "github.com/corentings/goTeaching/goroutines_sum_square", Name: "BenchmarkSkewedWorkload/static", Size: 0, Procs: 1,
+			Iterations: 100, NsPerOp: 75183, GOOS: "linux", GOARCH: "amd64", CPU: cpu},
+	}
+
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("actual %+v\nexpected %+v", results, expected)
+	}
+	if name := results[2].Benchmark(); name != "BenchmarkKernels" {
+		t.Errorf("benchmark %s expected BenchmarkKernels", name)
+	}
+}
+
+func TestParseBenchmarkFile(t *testing.T) {
+	file, err := os.Open("../goroutines_simple_vs_complex/benchmark.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	results, err := Parse(file)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(results) != 15 {
+		t.Fatalf("%d results expected 15", len(results))
+	}
+
+	first := results[0]
+	if first.Name != "Benchmark_SimpleConvertPineApplesToSafety/Benchmark_SimpleConvertPineApplesToSafety-500" ||
+		first.Size != 500 || first.Procs != 32 || first.NsPerOp != 15797 || first.Package != "github.com/corentings/goTeaching/goroutines_simple" {
+		t.Errorf("unexpected first result %+v", first)
+	}
+	if last := results[14]; last.Size != 10000 || last.Benchmark() != "Benchmark_NoMutexGoroutinesConvertPineApplesToSafety" {
+		t.Errorf("unexpected last result %+v", last)
+	}
+}
+
+func TestParseProcs(t *testing.T) {
+	const convert = "Benchmark_SimpleConvertPineApplesToSafety/Benchmark_SimpleConvertPineApplesToSafety"
+	tests := []struct {
+		name   string
+		lines  string
+		expect [][2]int // size and procs of each result
+	}{
+		{"size and procs", convert + "-500-8 1 1 ns/op\n" + convert + "-1000-8 1 1 ns/op\n", [][2]int{{500, 8}, {1000, 8}}},
+		{"size without procs", convert + "-500 1 1 ns/op\n" + convert + "-1000 1 1 ns/op\n", [][2]int{{500, 1}, {1000, 1}}},
+		{"size without procs next to a bare name", "BenchmarkX/static 1 1 ns/op\n" + convert + "-500 1 1 ns/op\n", [][2]int{{0, 1}, {500, 1}}},
+		{"procs only", "BenchmarkX-8 1 1 ns/op\nBenchmarkY/static-8 1 1 ns/op\n", [][2]int{{0, 8}, {0, 8}}},
+		{"procs known from a size", "BenchmarkX/static-8 1 1 ns/op\nBenchmarkY/500-8 1 1 ns/op\n", [][2]int{{0, 8}, {500, 8}}},
+		{"cpu list", "BenchmarkX 1 1 ns/op\nBenchmarkX-2 1 1 ns/op\nBenchmarkX-4 1 1 ns/op\n", [][2]int{{0, 1}, {0, 2}, {0, 4}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, err := Parse(strings.NewReader("pkg: p\n" + test.lines))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != len(test.expect) {
+				t.Fatalf("%d results expected %d", len(results), len(test.expect))
+			}
+			for i, r := range results {
+				if r.Size != test.expect[i][0] || r.Procs != test.expect[i][1] {
+					t.Errorf("%s: size %d procs %d, expected %v", r.Name, r.Size, r.Procs, test.expect[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseInvalidValue(t *testing.T) {
+	if _, err := Parse(strings.NewReader("BenchmarkX-8  10  abc ns/op\n")); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	results, err := Parse(strings.NewReader(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, results, JSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []Result
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || !reflect.DeepEqual(decoded, results) {
+		t.Errorf("JSON round trip failed: %v", err)
+	}
+
+	buf.Reset()
+	if err := Write(&buf, results, CSV); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(results)+1 || !reflect.DeepEqual(records[0], csvHeader) {
+		t.Fatalf("unexpected CSV records %v", records)
+	}
+	if records[3][1] != "BenchmarkKernels/avx2/1000" || records[3][9] != "25.58 GB/s" {
+		t.Errorf("unexpected CSV record %v", records[3])
+	}
+
+	buf.Reset()
+	if err := Write(&buf, results, Markdown); err != nil {
+		t.Fatal(err)
+	}
+	markdown := buf.String()
+	for _, expected := range []string{
+		"### github.com/corentings/goTeaching/goroutines_merge_sort\n",
+		"### github.com/corentings/goTeaching/goroutines_sum_square\n",
+		"| BenchmarkMergesort/500 | 500 | 32 | 41253 | 40960 | 499 |  |\n",
+		"| BenchmarkKernels/avx2/1000 | 1000 | 1 | 317.1 | 0 | 0 | 25.58 GB/s, 25226.25 MB/s |\n",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("markdown does not contain %q:\n%s", expected, markdown)
+		}
+	}
+
+	if err := Write(&buf, results, "xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,233 @@
+package benchmarks
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrNoData is returned when a chart has no point to draw
+	ErrNoData = errors.New("benchmarks: no data to chart")
+	// ErrNoSize is returned for results which can't be charted since their name has no size
+	ErrNoSize = errors.New("benchmarks: no size in the name")
+)
+
+// Point is the median ns/op of a benchmark for one input size
+type Point struct {
+	Size    float64
+	NsPerOp float64
+}
+
+// Series are the points of one implementation, sorted by size
+type Series struct {
+	Name   string
+	Points []Point
+}
+
+// SeriesByBenchmark builds one series per top level benchmark function, in the order of the results.
+// The samples of a size are reduced to their median. The results without a size are left out of the series
+// and named in an ErrNoSize error, so that a chart isn't silently missing them.
+func SeriesByBenchmark(results []Result) ([]Series, error) {
+	var names, skipped []string
+	samples := make(map[string]map[int][]float64)
+	for _, r := range results {
+		if r.Size <= 0 {
+			if len(skipped) == 0 || skipped[len(skipped)-1] != r.Name { // the samples of -count follow each other
+				skipped = append(skipped, r.Name)
+			}
+			continue
+		}
+		if r.NsPerOp <= 0 {
+			continue
+		}
+
+		name := strings.TrimLeft(strings.TrimPrefix(r.Benchmark(), "Benchmark"), "_")
+		if _, ok := samples[name]; !ok {
+			names = append(names, name)
+			samples[name] = make(map[int][]float64)
+		}
+		samples[name][r.Size] = append(samples[name][r.Size], r.NsPerOp)
+	}
+
+	series := make([]Series, len(names))
+	for i, name := range names {
+		series[i].Name = name
+		for size, values := range samples[name] {
+			series[i].Points = append(series[i].Points, Point{Size: float64(size), NsPerOp: Summarize(values, 0.95).Median})
+		}
+		sort.Slice(series[i].Points, func(a, b int) bool { return series[i].Points[a].Size < series[i].Points[b].Size })
+	}
+
+	if len(skipped) > 0 {
+		return series, fmt.Errorf("%w, left out %s", ErrNoSize, strings.Join(skipped, ", "))
+	}
+	return series, nil
+}
+
+// Chart plots ns/op against the input size on a log/log scale
+type Chart struct {
+	Title  string
+	Width  int // of the plot area, 640 by default
+	Height int // of the plot area, 360 by default
+	Series []Series
+}
+
+// colors of the series, from the Tableau 10 palette
+var colors = []string{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac"}
+
+const (
+	marginLeft   = 80
+	marginRight  = 30
+	marginTop    = 50
+	marginBottom = 60
+	legendLine   = 20
+)
+
+// logRange is the range of decades covering the values
+type logRange struct{ min, max float64 }
+
+func newLogRange(values []float64) logRange {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		low, high = math.Min(low, math.Log10(v)), math.Max(high, math.Log10(v))
+	}
+
+	r := logRange{min: math.Floor(low), max: math.Ceil(high)}
+	if r.max == r.min {
+		r.max++
+	}
+	return r
+}
+
+// scale maps v to [0, length]
+func (r logRange) scale(v float64, length int) float64 {
+	return (math.Log10(v) - r.min) / (r.max - r.min) * float64(length)
+}
+
+// ticks returns 1, 2 and 5 times the powers of ten for narrow ranges, only the powers of ten otherwise
+func (r logRange) ticks() []float64 {
+	multiples := []float64{1, 2, 5}
+	if r.max-r.min > 3 {
+		multiples = []float64{1}
+	}
+
+	var ticks []float64
+	for decade := r.min; decade <= r.max; decade++ {
+		for _, m := range multiples {
+			if tick := m * math.Pow(10, decade); math.Log10(tick) <= r.max+1e-9 {
+				ticks = append(ticks, tick)
+			}
+		}
+	}
+	return ticks
+}
+
+// WriteSVG renders the chart as a standalone SVG document
+func (c Chart) WriteSVG(w io.Writer) error {
+	var sizes, times []float64
+	for _, s := range c.Series {
+		for _, p := range s.Points {
+			sizes = append(sizes, p.Size)
+			times = append(times, p.NsPerOp)
+		}
+	}
+	if len(sizes) == 0 {
+		return ErrNoData
+	}
+
+	width, height := c.Width, c.Height
+	if width <= 0 {
+		width = 640
+	}
+	if height <= 0 {
+		height = 360
+	}
+	xRange, yRange := newLogRange(sizes), newLogRange(times)
+	x := func(v float64) float64 { return marginLeft + xRange.scale(v, width) }
+	y := func(v float64) float64 { return marginTop + float64(height) - yRange.scale(v, height) }
+
+	totalWidth := marginLeft + width + marginRight
+	totalHeight := marginTop + height + marginBottom + legendLine*len(c.Series)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		totalWidth, totalHeight, totalWidth, totalHeight)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="white"/>`+"\n", totalWidth, totalHeight)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" font-size="16">%s</text>`+"\n", totalWidth/2, marginTop/2+5, escape(c.Title))
+
+	// Grid and axes
+	for _, tick := range xRange.ticks() {
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="#ddd"/>`+"\n", x(tick), marginTop, x(tick), marginTop+height)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`+"\n", x(tick), marginTop+height+18, formatSize(tick))
+	}
+	for _, tick := range yRange.ticks() {
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ddd"/>`+"\n", marginLeft, y(tick), marginLeft+width, y(tick))
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end">%s</text>`+"\n", marginLeft-6, y(tick)+4, formatDuration(tick))
+	}
+	fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="#333"/>`+"\n", marginLeft, marginTop, width, height)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">input size</text>`+"\n", marginLeft+width/2, marginTop+height+40)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" transform="rotate(-90 %d %d)">time per operation</text>`+"\n",
+		18, marginTop+height/2, 18, marginTop+height/2)
+
+	// Series and legend
+	for i, s := range c.Series {
+		color := colors[i%len(colors)]
+
+		points := make([]string, len(s.Points))
+		for j, p := range s.Points {
+			points[j] = fmt.Sprintf("%.1f,%.1f", x(p.Size), y(p.NsPerOp))
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n", strings.Join(points, " "), color)
+		for _, p := range s.Points {
+			fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s: %s at %s</title></circle>`+"\n",
+				x(p.Size), y(p.NsPerOp), color, escape(s.Name), formatDuration(p.NsPerOp), formatSize(p.Size))
+		}
+
+		legendY := marginTop + height + marginBottom + legendLine*i
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="2"/>`+"\n", marginLeft, legendY, marginLeft+24, legendY, color)
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`+"\n", marginLeft+32, legendY+4, escape(s.Name))
+	}
+
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s)) // a strings.Builder never fails
+	return b.String()
+}
+
+// formatSize writes 1000 as 1k and 2000000 as 2M
+func formatSize(v float64) string {
+	switch {
+	case v >= 1e9:
+		return fmt.Sprintf("%gG", v/1e9)
+	case v >= 1e6:
+		return fmt.Sprintf("%gM", v/1e6)
+	case v >= 1e3:
+		return fmt.Sprintf("%gk", v/1e3)
+	default:
+		return fmt.Sprintf("%g", v)
+	}
+}
+
+// formatDuration writes nanoseconds with the largest unit below them
+func formatDuration(ns float64) string {
+	switch {
+	case ns >= 1e9:
+		return fmt.Sprintf("%.3gs", ns/1e9)
+	case ns >= 1e6:
+		return fmt.Sprintf("%.3gms", ns/1e6)
+	case ns >= 1e3:
+		return fmt.Sprintf("%.3gµs", ns/1e3)
+	default:
+		return fmt.Sprintf("%.3gns", ns)
+	}
+}
@@ -0,0 +1,105 @@
+package benchmarks
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSeriesByBenchmark(t *testing.T) {
+	results := []Result{
+		{Name: "Benchmark_Simple/Benchmark_Simple-1000", Size: 1000, NsPerOp: 30},
+		{Name: "Benchmark_Simple/Benchmark_Simple-500", Size: 500, NsPerOp: 10},
+		{Name: "Benchmark_Simple/Benchmark_Simple-500", Size: 500, NsPerOp: 16},
+		{Name: "Benchmark_Simple/Benchmark_Simple-500", Size: 500, NsPerOp: 12},
+		{Name: "BenchmarkMergesort/500", Size: 500, NsPerOp: 20},
+		{Name: "BenchmarkSkewedWorkload/static", NsPerOp: 20},
+	}
+
+	expected := []Series{
+		{Name: "Simple", Points: []Point{{500, 12}, {1000, 30}}},
+		{Name: "Mergesort", Points: []Point{{500, 20}}},
+	}
+	series, err := SeriesByBenchmark(results)
+	if !reflect.DeepEqual(series, expected) {
+		t.Errorf("actual %+v expected %+v", series, expected)
+	}
+	if !errors.Is(err, ErrNoSize) || !strings.Contains(err.Error(), "BenchmarkSkewedWorkload/static") {
+		t.Errorf("error %v expected to name the result without size", err)
+	}
+
+	if _, err := SeriesByBenchmark(results[:5]); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestChartWriteSVG(t *testing.T) {
+	file, err := Parse(strings.NewReader(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	series, err := SeriesByBenchmark(file)
+	if !errors.Is(err, ErrNoSize) {
+		t.Errorf("error %v expected for BenchmarkSkewedWorkload", err)
+	}
+	chart := Chart{Title: "Merge sort <scaling>", Series: series}
+	var buf bytes.Buffer
+	if err := chart.WriteSVG(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// The document must be well formed XML with one line per series
+	polylines, texts := 0, []string{}
+	decoder := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %v\n%s", err, buf.String())
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if token.Name.Local == "polyline" {
+				polylines++
+			}
+		case xml.CharData:
+			texts = append(texts, string(token))
+		}
+	}
+
+	if polylines != 2 {
+		t.Errorf("%d polylines expected 2", polylines)
+	}
+	for _, expected := range []string{"Merge sort <scaling>", "Mergesort", "Kernels", "1k", "100µs"} {
+		if !strings.Contains(strings.Join(texts, "\n"), expected) {
+			t.Errorf("the chart does not contain the text %q", expected)
+		}
+	}
+
+	if err := (Chart{}).WriteSVG(&buf); !errors.Is(err, ErrNoData) {
+		t.Errorf("expected %v got %v", ErrNoData, err)
+	}
+}
+
+func TestLogRange(t *testing.T) {
+	r := newLogRange([]float64{500, 10000})
+	if r != (logRange{min: 2, max: 4}) {
+		t.Errorf("unexpected range %+v", r)
+	}
+	if ticks := r.ticks(); !reflect.DeepEqual(ticks, []float64{100, 200, 500, 1000, 2000, 5000, 10000}) {
+		t.Errorf("unexpected ticks %v", ticks)
+	}
+	if r := newLogRange([]float64{1000}); r != (logRange{min: 3, max: 4}) {
+		t.Errorf("a single decade must be widened, got %+v", r)
+	}
+	if ticks := newLogRange([]float64{10, 1e7}).ticks(); !reflect.DeepEqual(ticks, []float64{10, 100, 1000, 1e4, 1e5, 1e6, 1e7}) {
+		t.Errorf("unexpected ticks %v", ticks)
+	}
+}
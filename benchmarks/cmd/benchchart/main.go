@@ -0,0 +1,71 @@
+// Command benchchart draws the ns/op of benchmarks against their input size as an SVG chart,
+// one series per benchmark function, on a log/log scale.
+//
+//	benchchart -input benchmark.txt -o benchmark.svg -title "Conversion of pineapples"
+//	go test -run '^$' -bench . ./goroutines_merge_sort | benchchart -o mergesort.svg
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"regexp"
+
+	"github.com/corentings/goTeaching/benchmarks"
+)
+
+func main() {
+	input := flag.String("input", "", "go test -bench output, the standard input by default")
+	output := flag.String("o", "", "SVG file, the standard output by default")
+	title := flag.String("title", "", "title of the chart")
+	filter := flag.String("bench", ".", "regular expression selecting the benchmarks to draw")
+	flag.Parse()
+
+	bench, err := regexp.Compile(*filter)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var in io.Reader = os.Stdin
+	if *input != "" {
+		data, err := os.ReadFile(*input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		in = bytes.NewReader(data)
+	}
+
+	results, err := benchmarks.Parse(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var selected []benchmarks.Result
+	for _, r := range results {
+		if bench.MatchString(r.Name) {
+			selected = append(selected, r)
+		}
+	}
+
+	series, err := benchmarks.SeriesByBenchmark(selected)
+	if err != nil {
+		log.Print(err) // the other results are still drawn, -bench can leave these out
+	}
+
+	var svg bytes.Buffer
+	chart := benchmarks.Chart{Title: *title, Series: series}
+	if err := chart.WriteSVG(&svg); err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(svg.Bytes())
+	} else {
+		err = os.WriteFile(*output, svg.Bytes(), 0o644)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
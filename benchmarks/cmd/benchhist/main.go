@@ -0,0 +1,144 @@
+// Command benchhist saves benchmark runs in a history file and compares two of them.
+//
+// Save the output of go test -bench, with the current commit and machine, then compare the
+// two latest runs. Use -count so that every benchmark has enough samples for the statistics:
+//
+//	go test -run '^$' -bench . -benchmem -count 10 ./goroutines_merge_sort | benchhist save
+//	benchhist compare
+//	benchhist compare -old 3f2a9c1 -new -1 -threshold 0.1
+//
+// compare exits with status 1 when a watched benchmark is significantly slower than the threshold.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/corentings/goTeaching/benchmarks"
+)
+
+// watched are the benchmarks that fail compare when they regress
+const watched = `^(BenchmarkMergesort|BenchmarkMergesortWithGoroutines|Benchmark_\w*ConvertPineApplesToSafety)(/|$)`
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		log.Fatal("usage: benchhist save|compare [flags]")
+	}
+
+	switch os.Args[1] {
+	case "save":
+		save(os.Args[2:])
+	case "compare":
+		compare(os.Args[2:])
+	default:
+		log.Fatalf("unknown command %q, expected save or compare", os.Args[1])
+	}
+}
+
+func save(args []string) {
+	flags := flag.NewFlagSet("save", flag.ExitOnError)
+	history := flags.String("history", "bench-history.ndjson", "history file")
+	input := flags.String("input", "", "go test -bench output, the standard input by default")
+	_ = flags.Parse(args)
+
+	var in io.Reader = os.Stdin
+	if *input != "" {
+		data, err := os.ReadFile(*input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		in = bytes.NewReader(data)
+	}
+
+	results, err := benchmarks.Parse(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(results) == 0 {
+		log.Fatal("no benchmark result in the input")
+	}
+
+	commit, dirty := gitCommit()
+	if err := benchmarks.AppendRun(*history, benchmarks.NewRun(results, commit, dirty)); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Saved %d results of commit %s in %s", len(results), commit, *history)
+}
+
+func compare(args []string) {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	history := flags.String("history", "bench-history.ndjson", "history file")
+	oldRef := flags.String("old", "-2", "old run: index in the history, negative from the end, or commit prefix")
+	newRef := flags.String("new", "-1", "new run: index in the history, negative from the end, or commit prefix")
+	alpha := flags.Float64("alpha", 0.05, "significance level of the p-value")
+	threshold := flags.Float64("threshold", 0.05, "relative slowdown of the median above which a watched benchmark fails")
+	pattern := flags.String("watch", watched, "regular expression of the benchmarks checked for regressions")
+	_ = flags.Parse(args)
+
+	watch, err := regexp.Compile(*pattern)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	runs, err := benchmarks.LoadRuns(*history)
+	if err != nil {
+		log.Fatal(err)
+	}
+	oldRun, err := benchmarks.FindRun(runs, *oldRef)
+	if err != nil {
+		log.Fatal(err)
+	}
+	newRun, err := benchmarks.FindRun(runs, *newRef)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("old: %s %s on %s\nnew: %s %s on %s\n\n", describe(oldRun), oldRun.Time.Format("2006-01-02 15:04"), oldRun.Machine.CPU,
+		describe(newRun), newRun.Time.Format("2006-01-02 15:04"), newRun.Machine.CPU)
+
+	comparisons := benchmarks.Compare(oldRun.Results, newRun.Results, 1-*alpha)
+	if err := benchmarks.WriteComparisons(os.Stdout, comparisons, *alpha); err != nil {
+		log.Fatal(err)
+	}
+
+	regressions := benchmarks.Regressions(comparisons, watch, *alpha, *threshold)
+	if len(regressions) == 0 {
+		return
+	}
+
+	fmt.Println()
+	for _, r := range regressions {
+		fmt.Printf("REGRESSION %s: %+.2f%% (p=%.3f)\n", r.Name, 100*r.Delta, r.P)
+	}
+	os.Exit(1)
+}
+
+func describe(run benchmarks.Run) string {
+	commit := run.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	if run.Dirty {
+		commit += "+dirty"
+	}
+	return commit
+}
+
+// gitCommit returns the current commit and whether the working tree has changes, "unknown" outside of a repository
+func gitCommit() (string, bool) {
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "unknown", false
+	}
+
+	status, err := exec.Command("git", "status", "--porcelain").Output()
+	return strings.TrimSpace(string(out)), err == nil && len(bytes.TrimSpace(status)) > 0
+}
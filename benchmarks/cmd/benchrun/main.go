@@ -0,0 +1,102 @@
+// Command benchrun runs the benchmarks of the lessons and writes the results as JSON, CSV or Markdown.
+//
+// Run it from the root of the module:
+//
+//	go run ./benchmarks/cmd/benchrun -format markdown -o results.md
+//	go run ./benchmarks/cmd/benchrun -bench Mergesort -benchtime 100x ./goroutines_merge_sort
+//
+// An existing output of go test -bench can be converted with -input instead:
+//
+//	go run ./benchmarks/cmd/benchrun -input goroutines_simple_vs_complex/benchmark.txt -format csv
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+
+	"github.com/corentings/goTeaching/benchmarks"
+)
+
+// lessons are the packages benchmarked when none is given on the command line
+var lessons = []string{
+	"./goroutines_merge_sort",
+	"./goroutines_sum_square",
+	"./goroutines_simple_vs_complex",
+	"./leetcode/rotate_array",
+}
+
+func main() {
+	bench := flag.String("bench", ".", "regular expression selecting the benchmarks, passed to go test -bench")
+	benchtime := flag.String("benchtime", "", "passed to go test -benchtime")
+	count := flag.String("count", "", "passed to go test -count")
+	format := flag.String("format", "json", "output format: json, csv or markdown")
+	output := flag.String("o", "", "output file, the standard output by default")
+	input := flag.String("input", "", "parse this go test -bench output instead of running the benchmarks")
+	verbose := flag.Bool("v", false, "print the raw go test output on the standard error")
+	flag.Parse()
+
+	var raw io.Reader
+	if *input != "" {
+		data, err := os.ReadFile(*input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		raw = bytes.NewReader(data)
+	} else {
+		packages := flag.Args()
+		if len(packages) == 0 {
+			packages = lessons
+		}
+		raw = run(packages, *bench, *benchtime, *count, *verbose)
+	}
+
+	results, err := benchmarks.Parse(raw)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := benchmarks.Write(out, results, benchmarks.Format(*format)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run executes go test on every package and returns its output
+func run(packages []string, bench, benchtime, count string, verbose bool) io.Reader {
+	args := []string{"test", "-run", "^$", "-bench", bench, "-benchmem"}
+	if benchtime != "" {
+		args = append(args, "-benchtime", benchtime)
+	}
+	if count != "" {
+		args = append(args, "-count", count)
+	}
+	args = append(args, packages...)
+
+	var out bytes.Buffer
+	cmd := exec.Command("go", args...)
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if verbose {
+		cmd.Stdout = io.MultiWriter(&out, os.Stderr)
+	}
+
+	log.Printf("go %v", args)
+	if err := cmd.Run(); err != nil {
+		os.Stderr.Write(out.Bytes())
+		log.Fatal(err)
+	}
+	return &out
+}
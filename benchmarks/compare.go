@@ -0,0 +1,120 @@
+package benchmarks
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"text/tabwriter"
+)
+
+// Comparison is the change of the ns/op of a benchmark between two runs
+type Comparison struct {
+	Package string  `json:"package"`
+	Name    string  `json:"name"`
+	Size    int     `json:"size"`
+	Procs   int     `json:"procs"`
+	Old     Summary `json:"old"`
+	New     Summary `json:"new"`
+	Delta   float64 `json:"delta"` // relative change of the median, 0.1 is 10% slower
+	P       float64 `json:"p"`
+}
+
+// Significant reports whether the change is unlikely to be noise, given the significance level alpha
+func (c Comparison) Significant(alpha float64) bool {
+	return c.P < alpha
+}
+
+// Regression reports whether the benchmark got significantly slower by more than threshold, 0.05 being 5%
+func (c Comparison) Regression(alpha, threshold float64) bool {
+	return c.Significant(alpha) && c.Delta > threshold
+}
+
+// Compare matches the benchmarks present in both runs and compares their ns/op.
+// Every result with the same package, name, size and procs is a sample, as produced by go test -count,
+// so the runs of go test -cpu 1,8 are compared separately.
+func Compare(old, new []Result, confidence float64) []Comparison {
+	type key struct {
+		pkg, name   string
+		size, procs int
+	}
+	samples := func(results []Result) ([]key, map[key][]float64) {
+		var order []key
+		byKey := make(map[key][]float64)
+		for _, r := range results {
+			k := key{r.Package, r.Name, r.Size, r.Procs}
+			if _, ok := byKey[k]; !ok {
+				order = append(order, k)
+			}
+			byKey[k] = append(byKey[k], r.NsPerOp)
+		}
+		return order, byKey
+	}
+
+	_, oldSamples := samples(old)
+	order, newSamples := samples(new)
+
+	var comparisons []Comparison
+	for _, k := range order {
+		before, ok := oldSamples[k]
+		if !ok {
+			continue
+		}
+		after := newSamples[k]
+
+		c := Comparison{
+			Package: k.pkg,
+			Name:    k.name,
+			Size:    k.size,
+			Procs:   k.procs,
+			Old:     Summarize(before, confidence),
+			New:     Summarize(after, confidence),
+			P:       MannWhitney(before, after),
+		}
+		if c.Old.Median != 0 {
+			c.Delta = (c.New.Median - c.Old.Median) / c.Old.Median
+		}
+		comparisons = append(comparisons, c)
+	}
+
+	return comparisons
+}
+
+// Regressions returns the comparisons of the benchmarks matching watched that regressed
+func Regressions(comparisons []Comparison, watched *regexp.Regexp, alpha, threshold float64) []Comparison {
+	var regressions []Comparison
+	for _, c := range comparisons {
+		if watched.MatchString(c.Name) && c.Regression(alpha, threshold) {
+			regressions = append(regressions, c)
+		}
+	}
+	return regressions
+}
+
+// WriteComparisons prints the comparisons as a table in the style of benchstat,
+// changes which are not significant are shown as "~"
+func WriteComparisons(w io.Writer, comparisons []Comparison, alpha float64) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "name\told ns/op\tnew ns/op\tdelta\t")
+
+	interval := func(s Summary) string {
+		if s.Median == 0 {
+			return fmt.Sprintf("%.4g", s.Median)
+		}
+		spread := 100 * (s.High - s.Low) / 2 / s.Median
+		return fmt.Sprintf("%.4g ±%.0f%%", s.Median, spread)
+	}
+
+	for _, c := range comparisons {
+		name := c.Name
+		if c.Procs > 1 {
+			name = fmt.Sprintf("%s-%d", name, c.Procs) // as printed by go test
+		}
+		delta := "~"
+		if c.Significant(alpha) {
+			delta = fmt.Sprintf("%+.2f%%", 100*c.Delta)
+		}
+		fmt.Fprintf(tw, "%s\t%s (n=%d)\t%s (n=%d)\t%s (p=%.3f)\t\n", name, interval(c.Old), c.Old.N, interval(c.New), c.New.N, delta, c.P)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,119 @@
+package benchmarks
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrRunNotFound is returned by FindRun when no run matches the reference
+var ErrRunNotFound = errors.New("benchmarks: run not found")
+
+// Machine describes the computer a run was made on
+type Machine struct {
+	Hostname  string `json:"hostname"`
+	GOOS      string `json:"goos"`
+	GOARCH    string `json:"goarch"`
+	CPU       string `json:"cpu"`
+	NumCPU    int    `json:"num_cpu"`
+	GoVersion string `json:"go_version"`
+}
+
+// Run is a set of results saved in the history
+type Run struct {
+	Time    time.Time `json:"time"`
+	Commit  string    `json:"commit"`
+	Dirty   bool      `json:"dirty"` // the working tree had uncommitted changes
+	Machine Machine   `json:"machine"`
+	Results []Result  `json:"results"`
+}
+
+// NewRun returns a run of the results made now on this machine
+func NewRun(results []Result, commit string, dirty bool) Run {
+	hostname, _ := os.Hostname()
+	machine := Machine{
+		Hostname:  hostname,
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		NumCPU:    runtime.NumCPU(),
+		GoVersion: runtime.Version(),
+	}
+	if len(results) > 0 {
+		machine.CPU = results[0].CPU
+	}
+
+	return Run{Time: time.Now().UTC(), Commit: commit, Dirty: dirty, Machine: machine, Results: results}
+}
+
+// AppendRun adds the run at the end of the history file, one JSON run per line
+func AppendRun(path string, run Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadRuns reads every run of the history file, from the oldest to the newest
+func LoadRuns(path string) ([]Run, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024) // a run with -count holds many results
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			return nil, fmt.Errorf("benchmarks: %s line %d: %w", path, line, err)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, scanner.Err()
+}
+
+// FindRun returns the run matching ref, which is either an index in the history,
+// negative indexes counting from the end (-1 is the latest run), or a commit prefix
+// in which case the latest run of that commit is returned.
+func FindRun(runs []Run, ref string) (Run, error) {
+	if idx, err := strconv.Atoi(ref); err == nil {
+		if idx < 0 {
+			idx += len(runs)
+		}
+		if idx < 0 || idx >= len(runs) {
+			return Run{}, fmt.Errorf("%w: index %s out of %d runs", ErrRunNotFound, ref, len(runs))
+		}
+		return runs[idx], nil
+	}
+
+	for i := len(runs) - 1; i >= 0; i-- {
+		if ref != "" && strings.HasPrefix(runs[i].Commit, ref) {
+			return runs[i], nil
+		}
+	}
+	return Run{}, fmt.Errorf("%w: commit %q", ErrRunNotFound, ref)
+}
@@ -0,0 +1,56 @@
+package benchmarks
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.ndjson")
+
+	if _, err := LoadRuns(path); err == nil {
+		t.Error("expected an error for a missing history")
+	}
+
+	results, err := Parse(strings.NewReader(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := NewRun(results[:2], "aaaa1111", false)
+	second := NewRun(results, "bbbb2222", true)
+	third := NewRun(results[2:], "aaaa1111", false)
+	for _, run := range []Run{first, second, third} {
+		if err := AppendRun(path, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, err := LoadRuns(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 || !reflect.DeepEqual(runs[1].Results, results) || !runs[1].Dirty || runs[1].Machine.CPU != results[0].CPU {
+		t.Fatalf("unexpected runs %+v", runs)
+	}
+
+	for ref, expected := range map[string]int{"0": 0, "1": 1, "-1": 2, "-3": 0, "bbbb": 1, "aaaa1111": 2} {
+		run, err := FindRun(runs, ref)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", ref, err)
+			continue
+		}
+		if !reflect.DeepEqual(run, runs[expected]) {
+			t.Errorf("%s: found run of commit %s expected run %d", ref, run.Commit, expected)
+		}
+	}
+
+	for _, ref := range []string{"3", "-4", "cccc", ""} {
+		if _, err := FindRun(runs, ref); !errors.Is(err, ErrRunNotFound) {
+			t.Errorf("%q: expected %v got %v", ref, ErrRunNotFound, err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package benchmarks parses the output of go test -bench into structured results,
+// and writes them as JSON, CSV or Markdown so that runs can be stored and compared.
+package benchmarks
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Result is one line of benchmark output
+type Result struct {
+	Package string `json:"package"`
+	Name    string `json:"name"` // full name without the GOMAXPROCS suffix
+	Size    int    `json:"size"` // last number of the name, 0 if there is none
+	Procs   int    `json:"procs"`
+
+	Iterations  int     `json:"iterations"`
+	NsPerOp     float64 `json:"ns_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`  // only reported with -benchmem
+	AllocsPerOp int64   `json:"allocs_per_op"` // only reported with -benchmem
+	MBPerSec    float64 `json:"mb_per_sec,omitempty"`
+
+	// Metrics holds the custom units reported with b.ReportMetric
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+
+	GOOS   string `json:"goos"`
+	GOARCH string `json:"goarch"`
+	CPU    string `json:"cpu"`
+}
+
+// Benchmark returns the name of the top level benchmark function
+func (r Result) Benchmark() string {
+	name, _, _ := strings.Cut(r.Name, "/")
+	return name
+}
+
+// Parse reads the output of one or several go test -bench runs.
+// The goos, goarch, pkg and cpu headers apply to the results that follow them,
+// every other line which is not a benchmark result is ignored.
+func Parse(r io.Reader) ([]Result, error) {
+	var results []Result
+	var header Result
+	block := 0 // first result of the current package, whose procs are resolved together
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024) // names of sub-benchmarks can be long
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if key, value, ok := strings.Cut(text, ":"); ok && !strings.HasPrefix(text, "Benchmark") {
+			value = strings.TrimSpace(value)
+			switch key {
+			case "goos":
+				header.GOOS = value
+			case "goarch":
+				header.GOARCH = value
+			case "pkg":
+				resolveProcs(results[block:])
+				block = len(results)
+				header.Package = value
+			case "cpu":
+				header.CPU = value
+			}
+			continue
+		}
+
+		if !strings.HasPrefix(text, "Benchmark") {
+			continue
+		}
+
+		result, ok, err := parseLine(text, header)
+		if err != nil {
+			return nil, fmt.Errorf("benchmarks: line %d: %w", line, err)
+		}
+		if ok {
+			results = append(results, result)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("benchmarks: %w", err)
+	}
+	resolveProcs(results[block:])
+	return results, nil
+}
+
+// parseLine parses "BenchmarkName-8  1000  1234 ns/op  16 B/op  1 allocs/op".
+// ok is false for lines which only hold a name, as printed before the logs of a benchmark.
+func parseLine(text string, header Result) (result Result, ok bool, err error) {
+	fields := strings.Fields(text)
+	if len(fields) < 4 || len(fields)%2 != 0 {
+		return result, false, nil
+	}
+
+	iterations, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return result, false, nil
+	}
+
+	result = header
+	result.Name = fields[0] // the procs and the size are set by resolveProcs
+	result.Iterations = iterations
+
+	for i := 2; i < len(fields); i += 2 {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return result, false, fmt.Errorf("invalid value %q for %s", fields[i], fields[i+1])
+		}
+
+		switch unit := fields[i+1]; unit {
+		case "ns/op":
+			result.NsPerOp = value
+		case "B/op":
+			result.BytesPerOp = int64(value)
+		case "allocs/op":
+			result.AllocsPerOp = int64(value)
+		case "MB/s":
+			result.MBPerSec = value
+		default:
+			if result.Metrics == nil {
+				result.Metrics = make(map[string]float64)
+			}
+			result.Metrics[unit] = value
+		}
+	}
+
+	return result, true, nil
+}
+
+// resolveProcs removes the GOMAXPROCS suffix from the names of the results of a package, and sets their procs and size.
+//
+// go test only adds the -N suffix when GOMAXPROCS is not 1, so "Sort-500" is either the size 500 on one CPU
+// or Sort on 500 CPUs. The suffix is certain after a number, as in "Sort-500-8" or "Sort/500-8".
+// Otherwise it is taken as the procs when a certain suffix of the package has the same value, when the name also
+// appears without suffix as with -cpu 1,8, or when every result of the package ends with this same number.
+func resolveProcs(results []Result) {
+	certain := make(map[int]bool)
+	bare := make(map[string]bool)
+	ambiguous := make(map[int]bool)
+	for i := range results {
+		name, procs, ok := cutProcs(results[i].Name)
+		switch {
+		case !ok:
+			bare[name] = true
+		case sizeOf(name) != 0:
+			certain[procs] = true
+		default:
+			ambiguous[procs] = true
+		}
+	}
+	onlySuffix := len(certain) == 0 && len(bare) == 0 && len(ambiguous) == 1
+
+	for i := range results {
+		r := &results[i]
+		name, procs, ok := cutProcs(r.Name)
+		if ok && (sizeOf(name) != 0 || certain[procs] || bare[name] || onlySuffix) {
+			r.Name, r.Procs = name, procs
+		} else {
+			r.Procs = 1
+		}
+		r.Size = sizeOf(r.Name)
+	}
+}
+
+// cutProcs splits the trailing -N off the name, ok is false when the name doesn't end with one
+func cutProcs(name string) (string, int, bool) {
+	idx := strings.LastIndexByte(name, '-')
+	if idx < 0 {
+		return name, 1, false
+	}
+
+	procs, err := strconv.Atoi(name[idx+1:])
+	if err != nil || procs < 1 {
+		return name, 1, false
+	}
+	return name[:idx], procs, true
+}
+
+// sizeOf returns the last number of the name: "BenchmarkSort/500" and "BenchmarkSort/Sort-500" both have a size of 500
+func sizeOf(name string) int {
+	segments := strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '-' })
+	if len(segments) == 0 {
+		return 0
+	}
+
+	size, err := strconv.Atoi(segments[len(segments)-1])
+	if err != nil {
+		return 0
+	}
+	return size
+}
@@ -0,0 +1,158 @@
+package benchmarks
+
+import (
+	"math"
+	"sort"
+)
+
+// Summary describes the samples of a benchmark: their median and a confidence interval around it
+type Summary struct {
+	N      int     `json:"n"`
+	Median float64 `json:"median"`
+	Low    float64 `json:"low"`
+	High   float64 `json:"high"`
+}
+
+// Summarize returns the median of the samples and its confidence interval.
+// The interval is given by order statistics, so it makes no assumption on the distribution;
+// with too few samples to reach the confidence it covers all of them.
+func Summarize(samples []float64, confidence float64) Summary {
+	n := len(samples)
+	if n == 0 {
+		return Summary{}
+	}
+
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+
+	median := sorted[n/2]
+	if n%2 == 0 {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+
+	// The median is between the k-th smallest and the k-th largest samples
+	// with a probability of 1 - 2 * P(Binomial(n, 1/2) < k)
+	k := 0
+	for k < n/2 && 2*binomialCDF(n, k) <= 1-confidence {
+		k++
+	}
+	if k == 0 {
+		k = 1
+	}
+
+	return Summary{N: n, Median: median, Low: sorted[k-1], High: sorted[n-k]}
+}
+
+// binomialCDF returns P(X <= k) for X following Binomial(n, 1/2)
+func binomialCDF(n, k int) float64 {
+	total, coefficient := 0.0, 1.0
+	for i := 0; i <= k; i++ {
+		if i > 0 {
+			coefficient = coefficient * float64(n-i+1) / float64(i)
+		}
+		total += coefficient
+	}
+	return total / math.Pow(2, float64(n))
+}
+
+// MannWhitney returns the two-sided p-value of the Mann-Whitney U test, the probability of seeing
+// samples at least this different if a and b come from the same distribution.
+// The exact distribution of U is used for small samples without ties, the normal approximation otherwise.
+func MannWhitney(a, b []float64) float64 {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type sample struct {
+		value float64
+		first bool
+	}
+	all := make([]sample, 0, n1+n2)
+	for _, v := range a {
+		all = append(all, sample{v, true})
+	}
+	for _, v := range b {
+		all = append(all, sample{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].value < all[j].value })
+
+	// Rank the samples, tied values share the average of their ranks
+	rankSum, tieCorrection := 0.0, 0.0
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				rankSum += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			tieCorrection += t*t*t - t
+		}
+		i = j
+	}
+
+	u := rankSum - float64(n1*(n1+1))/2
+	if tieCorrection == 0 && n1+n2 <= 50 {
+		return exactMannWhitney(n1, n2, u)
+	}
+
+	n := float64(n1 + n2)
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * ((n + 1) - tieCorrection/(n*(n-1)))
+	if variance <= 0 {
+		return 1 // every sample is equal
+	}
+
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance) // continuity correction
+	if z < 0 {
+		return 1
+	}
+	return math.Min(1, math.Erfc(z/math.Sqrt2))
+}
+
+// exactMannWhitney counts the orderings of n1 + n2 samples giving each value of U
+func exactMannWhitney(n1, n2 int, u float64) float64 {
+	// counts[i][j][v] is the number of orderings of i and j samples with U = v, computed row by row
+	maxU := n1 * n2
+	previous := make([][]float64, n2+1)
+	for j := range previous {
+		previous[j] = make([]float64, maxU+1)
+		previous[j][0] = 1 // no sample of a: U is 0
+	}
+
+	for i := 1; i <= n1; i++ {
+		current := make([][]float64, n2+1)
+		current[0] = make([]float64, maxU+1)
+		current[0][0] = 1
+		for j := 1; j <= n2; j++ {
+			current[j] = make([]float64, maxU+1)
+			for v := 0; v <= maxU; v++ {
+				// the largest sample comes from a and is above the j samples of b, or it comes from b
+				if v >= j {
+					current[j][v] += previous[j][v-j]
+				}
+				current[j][v] += current[j-1][v]
+			}
+		}
+		previous = current
+	}
+
+	counts := previous[n2]
+	total, below, above := 0.0, 0.0, 0.0
+	for v, count := range counts {
+		total += count
+		if float64(v) <= u {
+			below += count
+		}
+		if float64(v) >= u {
+			above += count
+		}
+	}
+
+	return math.Min(1, 2*math.Min(below, above)/total)
+}
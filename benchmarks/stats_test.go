@@ -0,0 +1,131 @@
+package benchmarks
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	samples := []float64{10, 3, 7, 1, 9, 2, 8, 4, 6, 5}
+	// With 10 samples the 95% interval of the median goes from the 2nd to the 9th smallest
+	if s := Summarize(samples, 0.95); s != (Summary{N: 10, Median: 5.5, Low: 2, High: 9}) {
+		t.Errorf("unexpected summary %+v", s)
+	}
+	if s := Summarize([]float64{3, 1, 2}, 0.95); s != (Summary{N: 3, Median: 2, Low: 1, High: 3}) {
+		t.Errorf("too few samples should cover them all, got %+v", s)
+	}
+	if s := Summarize(nil, 0.95); s != (Summary{}) {
+		t.Errorf("empty summary %+v", s)
+	}
+	if samples[0] != 10 {
+		t.Error("Summarize must not sort the samples in place")
+	}
+}
+
+func TestMannWhitney(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []float64
+		expected float64
+	}{
+		// 2 orderings out of C(10, 5) = 252 are as extreme
+		{"separated", []float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10}, 2.0 / 252},
+		{"symmetric", []float64{6, 7, 8, 9, 10}, []float64{1, 2, 3, 4, 5}, 2.0 / 252},
+		{"single samples", []float64{1}, []float64{2}, 1},
+		{"identical", []float64{5, 5, 5}, []float64{5, 5, 5}, 1},
+		{"empty", nil, []float64{1}, 1},
+	}
+
+	for _, test := range tests {
+		if p := MannWhitney(test.a, test.b); math.Abs(p-test.expected) > 1e-9 {
+			t.Errorf("%s: p-value %v expected %v", test.name, p, test.expected)
+		}
+	}
+
+	// Large samples use the normal approximation
+	var low, high, even, odd []float64
+	for i := 0; i < 100; i++ {
+		low = append(low, float64(i))
+		high = append(high, float64(i+50))
+		even = append(even, float64(2*i))
+		odd = append(odd, float64(2*i+1))
+	}
+	if p := MannWhitney(low, high); p > 0.001 {
+		t.Errorf("shifted samples p-value %v expected below 0.001", p)
+	}
+	if p := MannWhitney(even, odd); p < 0.9 {
+		t.Errorf("interleaved samples p-value %v expected above 0.9", p)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	results := func(name string, values ...float64) []Result {
+		var out []Result
+		for _, v := range values {
+			out = append(out, Result{Package: "pkg", Name: name, NsPerOp: v})
+		}
+		return out
+	}
+
+	old := append(results("BenchmarkMergesort/1000", 100, 101, 99, 100, 102, 98),
+		results("BenchmarkRotateCopy/10", 10, 11, 10, 11, 10, 11)...)
+	old = append(old, results("BenchmarkGone", 1)...)
+	new := append(results("BenchmarkRotateCopy/10", 20, 21, 20, 21, 20, 21),
+		results("BenchmarkMergesort/1000", 120, 121, 119, 120, 122, 118)...)
+	new = append(new, results("BenchmarkAdded", 1)...)
+
+	comparisons := Compare(old, new, 0.95)
+	if len(comparisons) != 2 || comparisons[0].Name != "BenchmarkRotateCopy/10" || comparisons[1].Name != "BenchmarkMergesort/1000" {
+		t.Fatalf("unexpected comparisons %+v", comparisons)
+	}
+
+	sort := comparisons[1]
+	if sort.Old.Median != 100 || sort.New.Median != 120 || math.Abs(sort.Delta-0.2) > 1e-9 || !sort.Significant(0.05) {
+		t.Errorf("unexpected comparison %+v", sort)
+	}
+
+	watched := regexp.MustCompile(`^BenchmarkMergesort(/|$)`)
+	if regressions := Regressions(comparisons, watched, 0.05, 0.1); len(regressions) != 1 || regressions[0].Name != sort.Name {
+		t.Errorf("unexpected regressions %+v", regressions)
+	}
+	if regressions := Regressions(comparisons, watched, 0.05, 0.25); len(regressions) != 0 {
+		t.Errorf("a 20%% slowdown is below a 25%% threshold, got %+v", regressions)
+	}
+}
+
+func TestCompareSizesAndProcs(t *testing.T) {
+	results := func(size, procs int, values ...float64) []Result {
+		var out []Result
+		for _, v := range values {
+			out = append(out, Result{Package: "pkg", Name: "BenchmarkConvert", Size: size, Procs: procs, NsPerOp: v})
+		}
+		return out
+	}
+
+	old := append(results(500, 1, 10, 11, 10, 11, 10, 11), results(1000, 1, 20, 21, 20, 21, 20, 21)...)
+	old = append(old, results(1000, 8, 5, 6, 5, 6, 5, 6)...)
+	new := append(results(500, 1, 10, 11, 10, 11, 10, 11), results(1000, 1, 40, 41, 40, 41, 40, 41)...)
+	new = append(new, results(1000, 8, 5, 6, 5, 6, 5, 6)...)
+
+	comparisons := Compare(old, new, 0.95)
+	if len(comparisons) != 3 {
+		t.Fatalf("%d comparisons expected 3: %+v", len(comparisons), comparisons)
+	}
+	for _, c := range comparisons {
+		regressed := c.Size == 1000 && c.Procs == 1
+		if c.Old.N != 6 || c.New.N != 6 || c.Regression(0.05, 0.1) != regressed {
+			t.Errorf("unexpected comparison %+v", c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteComparisons(&buf, comparisons, 0.05); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "BenchmarkConvert-8") {
+		t.Errorf("procs missing from\n%s", buf.String())
+	}
+}
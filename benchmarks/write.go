@@ -0,0 +1,140 @@
+package benchmarks
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format is an output format of the results
+type Format string
+
+const (
+	JSON     Format = "json"
+	CSV      Format = "csv"
+	Markdown Format = "markdown"
+)
+
+// Write writes the results in the given format
+func Write(w io.Writer, results []Result, format Format) error {
+	switch format {
+	case JSON:
+		return WriteJSON(w, results)
+	case CSV:
+		return WriteCSV(w, results)
+	case Markdown:
+		return WriteMarkdown(w, results)
+	default:
+		return fmt.Errorf("benchmarks: unknown format %q", format)
+	}
+}
+
+// WriteJSON writes the results as an indented JSON array
+func WriteJSON(w io.Writer, results []Result) error {
+	if results == nil {
+		results = []Result{} // [] rather than null
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
+
+var csvHeader = []string{"package", "name", "size", "procs", "iterations", "ns_per_op", "bytes_per_op", "allocs_per_op", "mb_per_sec", "metrics", "goos", "goarch", "cpu"}
+
+// WriteCSV writes one row per result, the custom metrics are joined as "value unit;value unit"
+func WriteCSV(w io.Writer, results []Result) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		record := []string{
+			r.Package,
+			r.Name,
+			strconv.Itoa(r.Size),
+			strconv.Itoa(r.Procs),
+			strconv.Itoa(r.Iterations),
+			formatFloat(r.NsPerOp),
+			strconv.FormatInt(r.BytesPerOp, 10),
+			strconv.FormatInt(r.AllocsPerOp, 10),
+			formatFloat(r.MBPerSec),
+			formatMetrics(r.Metrics, ";"),
+			r.GOOS,
+			r.GOARCH,
+			r.CPU,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteMarkdown writes one table per package, in the order of the results
+func WriteMarkdown(w io.Writer, results []Result) error {
+	var packages []string
+	byPackage := make(map[string][]Result)
+	for _, r := range results {
+		if _, ok := byPackage[r.Package]; !ok {
+			packages = append(packages, r.Package)
+		}
+		byPackage[r.Package] = append(byPackage[r.Package], r)
+	}
+
+	var b strings.Builder
+	for i, pkg := range packages {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		first := byPackage[pkg][0]
+		fmt.Fprintf(&b, "### %s\n\n", pkg)
+		if first.CPU != "" {
+			fmt.Fprintf(&b, "%s/%s, %s\n\n", first.GOOS, first.GOARCH, first.CPU)
+		}
+
+		b.WriteString("| Benchmark | Size | Procs | ns/op | B/op | allocs/op | Other |\n")
+		b.WriteString("|---|---:|---:|---:|---:|---:|---|\n")
+		for _, r := range byPackage[pkg] {
+			other := formatMetrics(r.Metrics, ", ")
+			if r.MBPerSec != 0 {
+				if other != "" {
+					other += ", "
+				}
+				other += formatFloat(r.MBPerSec) + " MB/s"
+			}
+			fmt.Fprintf(&b, "| %s | %d | %d | %s | %d | %d | %s |\n",
+				strings.ReplaceAll(r.Name, "|", "\\|"), r.Size, r.Procs, formatFloat(r.NsPerOp), r.BytesPerOp, r.AllocsPerOp, other)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatMetrics sorts the units so that the output does not depend on the map order
+func formatMetrics(metrics map[string]float64, sep string) string {
+	units := make([]string, 0, len(metrics))
+	for unit := range metrics {
+		units = append(units, unit)
+	}
+	sort.Strings(units)
+
+	parts := make([]string, len(units))
+	for i, unit := range units {
+		parts[i] = formatFloat(metrics[unit]) + " " + unit
+	}
+	return strings.Join(parts, sep)
+}
@@ -1,7 +1,3 @@
 module github.com/corentings/goTeaching
 
-go 1.19
-
-require github.com/go-faker/faker/v4 v4.4.2
-
-require golang.org/x/text v0.15.0 // indirect
+go 1.20
@@ -0,0 +1,107 @@
+package goroutines_simple_vs_complex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// AdaptiveOptions configures AdaptiveMap
+type AdaptiveOptions struct {
+	SampleSize int           // number of items timed before deciding, 32 when 0 or less
+	MinWork    time.Duration // work a goroutine must get to pay for itself, 50µs when 0 or less
+	MaxWorkers int           // runtime.GOMAXPROCS(0) when 0 or less
+}
+
+// Decision is how AdaptiveMap ran a batch, and what it measured to decide
+type Decision struct {
+	Items     int           // size of the batch
+	Sampled   int           // items converted sequentially to measure their cost
+	PerItem   time.Duration // mean cost of a sampled item
+	Estimated time.Duration // sequential cost of the items left after the sample
+	Workers   int           // goroutines used for the items left, 1 is a simple loop
+}
+
+// Parallel tells whether the items left after the sample were converted by several goroutines
+func (d Decision) Parallel() bool {
+	return d.Workers > 1
+}
+
+func (d Decision) String() string {
+	mode := "sequential"
+	if d.Parallel() {
+		mode = fmt.Sprintf("%d-way parallel", d.Workers)
+	}
+	return fmt.Sprintf("%s: %d items, %v per item over %d sampled, %v estimated", mode, d.Items, d.PerItem, d.Sampled, d.Estimated)
+}
+
+// AdaptiveMap is ParallelMap choosing its number of workers from the measured cost of the items.
+//
+// The first items are converted sequentially and timed, without checking ctx.
+// The others are spread over as many workers as there is MinWork for each of them: cheap conversions
+// stay in a simple loop, which is faster than spawning goroutines, while expensive ones use every CPU.
+func AdaptiveMap[In, Out any](ctx context.Context, in []In, fn func(In) (Out, error), opts AdaptiveOptions) ([]Out, Decision, error) {
+	sampleSize := opts.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = 32
+	}
+	if sampleSize > len(in) {
+		sampleSize = len(in)
+	}
+
+	out := make([]Out, len(in))
+	decision := Decision{Items: len(in), Sampled: sampleSize, Workers: 1}
+
+	var errs []error
+	start := time.Now()
+	for i := 0; i < sampleSize; i++ {
+		result, err := fn(in[i])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("item %d: %w", i, err))
+			continue
+		}
+		out[i] = result
+	}
+	if sampleSize > 0 {
+		decision.PerItem = time.Since(start) / time.Duration(sampleSize)
+	}
+
+	left := len(in) - sampleSize
+	decision.Estimated = decision.PerItem * time.Duration(left)
+	decision.Workers = decideWorkers(decision.Estimated, left, opts)
+
+	err := parallelMapInto(ctx, in[sampleSize:], out[sampleSize:], sampleSize, fn, MapOptions{Workers: decision.Workers})
+	return out, decision, errors.Join(append(errs, err)...)
+}
+
+// decideWorkers gives every worker at least MinWork of the estimated cost, within MaxWorkers and the number of items
+func decideWorkers(estimated time.Duration, items int, opts AdaptiveOptions) int {
+	minWork := opts.MinWork
+	if minWork <= 0 {
+		minWork = 50 * time.Microsecond
+	}
+	maxWorkers := opts.MaxWorkers
+	if maxWorkers <= 0 {
+		maxWorkers = runtime.GOMAXPROCS(0)
+	}
+
+	workers := int(estimated / minWork)
+	if workers > maxWorkers {
+		workers = maxWorkers
+	}
+	if workers > items {
+		workers = items
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+// AdaptiveConvertPineApplesToSafety converts the pineapples with AdaptiveMap and returns the decision it took
+func AdaptiveConvertPineApplesToSafety(pineapples []Pineapple) ([]SafePineApple, Decision) {
+	safePineApples, decision, _ := AdaptiveMap(context.Background(), pineapples, toSafePineApple, AdaptiveOptions{})
+	return safePineApples, decision
+}
@@ -0,0 +1,171 @@
+package goroutines_simple_vs_complex
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecideWorkers(t *testing.T) {
+	opts := AdaptiveOptions{MinWork: 100 * time.Microsecond, MaxWorkers: 8}
+	tests := []struct {
+		estimated time.Duration
+		items     int
+		expected  int
+	}{
+		{0, 1000, 1},
+		{50 * time.Microsecond, 1000, 1},
+		{250 * time.Microsecond, 1000, 2},
+		{time.Millisecond, 1000, 8},
+		{time.Second, 3, 3},
+		{time.Second, 0, 1},
+	}
+	for _, test := range tests {
+		if actual := decideWorkers(test.estimated, test.items, opts); actual != test.expected {
+			t.Errorf("%v for %d items: %d workers expected %d", test.estimated, test.items, actual, test.expected)
+		}
+	}
+
+	if actual := decideWorkers(time.Hour, 1000, AdaptiveOptions{}); actual != runtime.GOMAXPROCS(0) {
+		t.Errorf("%d workers by default expected GOMAXPROCS %d", actual, runtime.GOMAXPROCS(0))
+	}
+}
+
+func TestAdaptiveMap(t *testing.T) {
+	in := make([]int, 200)
+	expected := make([]int, len(in))
+	for i := range in {
+		in[i] = i
+		expected[i] = i * i
+	}
+	square := func(i int) (int, error) { return i * i, nil }
+	slowSquare := func(i int) (int, error) {
+		time.Sleep(100 * time.Microsecond)
+		return i * i, nil
+	}
+
+	// The sleep makes every item cost at least 100µs, so the decisions only depend on MinWork and MaxWorkers
+	tests := []struct {
+		name    string
+		opts    AdaptiveOptions
+		workers int
+	}{
+		{"less than MinWork", AdaptiveOptions{MaxWorkers: 4, SampleSize: 8, MinWork: time.Hour}, 1},
+		{"more than MinWork", AdaptiveOptions{MaxWorkers: 4, SampleSize: 8, MinWork: time.Nanosecond}, 4},
+		{"single worker", AdaptiveOptions{MaxWorkers: 1, SampleSize: 8, MinWork: time.Nanosecond}, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, decision, err := AdaptiveMap(context.Background(), in, slowSquare, test.opts)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(out, expected) {
+				t.Errorf("actual %v expected %v", out, expected)
+			}
+			if decision.Workers != test.workers || decision.Items != len(in) || decision.Sampled != 8 || decision.PerItem < 100*time.Microsecond {
+				t.Errorf("unexpected decision %s, expected %d workers", decision, test.workers)
+			}
+		})
+	}
+
+	out, decision, err := AdaptiveMap(context.Background(), []int{}, square, AdaptiveOptions{})
+	if err != nil || len(out) != 0 || decision != (Decision{Workers: 1}) {
+		t.Errorf("empty input: %v %+v %v", out, decision, err)
+	}
+}
+
+func TestAdaptiveMapErrors(t *testing.T) {
+	errOdd := errors.New("odd")
+	in := make([]int, 100)
+	for i := range in {
+		in[i] = i
+	}
+
+	_, _, err := AdaptiveMap(context.Background(), in, func(i int) (int, error) {
+		if i == 3 || i == 97 {
+			return 0, errOdd
+		}
+		return i, nil
+	}, AdaptiveOptions{SampleSize: 10})
+	if !errors.Is(err, errOdd) || !strings.Contains(err.Error(), "item 3: odd") || !strings.Contains(err.Error(), "item 97: odd") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := AdaptiveMap(ctx, in, func(i int) (int, error) { return i, nil }, AdaptiveOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v got %v", context.Canceled, err)
+	}
+}
+
+func TestAdaptiveConvertPineApplesToSafety(t *testing.T) {
+	testConverter(t, func(pineApples []Pineapple) []SafePineApple {
+		safePineApples, decision := AdaptiveConvertPineApplesToSafety(pineApples)
+		// The cost is measured, so only check that the decision follows from it
+		if expected := decideWorkers(decision.Estimated, decision.Items-decision.Sampled, AdaptiveOptions{}); decision.Workers != expected {
+			t.Errorf("%d workers expected %d: %s", decision.Workers, expected, decision)
+		}
+		return safePineApples
+	})
+}
+
+// hashedSafePineApple is an artificially expensive conversion, which hashes the secret code many times
+func hashedSafePineApple(p Pineapple) (SafePineApple, error) {
+	sum := sha256.Sum256(p.SecretCode)
+	for i := 0; i < 256; i++ {
+		sum = sha256.Sum256(sum[:])
+	}
+	safe := p.ToSafePineApple()
+	safe.Banana = fmt.Sprintf("%x", sum[:4])
+	return safe, nil
+}
+
+func Benchmark_AdaptiveConvertPineApplesToSafety(b *testing.B) {
+	conversions := []struct {
+		name  string
+		fn    func(Pineapple) (SafePineApple, error)
+		sizes []int
+	}{
+		{"cheap", toSafePineApple, []int{100, 1000, 10000}},
+		{"hashed", hashedSafePineApple, []int{10, 100, 1000}},
+	}
+	strategies := []struct {
+		name    string
+		convert func(context.Context, []Pineapple, func(Pineapple) (SafePineApple, error)) Decision
+	}{
+		{"sequential", func(ctx context.Context, in []Pineapple, fn func(Pineapple) (SafePineApple, error)) Decision {
+			_, _ = ParallelMap(ctx, in, fn, MapOptions{Workers: 1})
+			return Decision{Workers: 1}
+		}},
+		{"parallel", func(ctx context.Context, in []Pineapple, fn func(Pineapple) (SafePineApple, error)) Decision {
+			_, _ = ParallelMap(ctx, in, fn, MapOptions{})
+			return Decision{Workers: runtime.NumCPU()}
+		}},
+		{"adaptive", func(ctx context.Context, in []Pineapple, fn func(Pineapple) (SafePineApple, error)) Decision {
+			_, decision, _ := AdaptiveMap(ctx, in, fn, AdaptiveOptions{})
+			return decision
+		}},
+	}
+
+	for _, conversion := range conversions {
+		for _, n := range conversion.sizes {
+			pineApples := fixtures(n)
+			for _, strategy := range strategies {
+				b.Run(fmt.Sprintf("%s/%s-%d", conversion.name, strategy.name, n), func(b *testing.B) {
+					var decision Decision
+					for i := 0; i < b.N; i++ {
+						decision = strategy.convert(context.Background(), pineApples, conversion.fn)
+					}
+					b.ReportMetric(float64(decision.Workers), "workers")
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Command pineapple-api serves an in-memory store of pineapples over HTTP, with their secret codes encrypted.
+//
+//	pineapple-api -addr 127.0.0.1:8080 &
+//	curl -d '{"Paro":"paro","Turkey":"turkey","Banana":"banana","Age":3,"SecretCode":"czNjcjN0"}' 127.0.0.1:8080/pineapples
+//	curl '127.0.0.1:8080/pineapples?name=par&sort=age&limit=10'
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/corentings/goTeaching/goroutines_simple_vs_complex/api"
+	"github.com/corentings/goTeaching/goroutines_simple_vs_complex/envelope"
+	"github.com/corentings/goTeaching/goroutines_simple_vs_complex/store"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "address to listen on")
+	flag.Parse()
+
+	// The store only lives in memory, so does its key
+	keyring, err := envelope.NewKeyring(1, envelope.GenerateKey())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           api.NewServer(store.NewEncryptedMemoryRepository(keyring)),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	log.Printf("Serving pineapples on %s", *addr)
+	log.Fatal(server.ListenAndServe())
+}
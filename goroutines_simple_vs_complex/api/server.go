@@ -0,0 +1,276 @@
+// Package api serves the pineapples of a store as JSON over HTTP.
+//
+//	GET    /pineapples       list, filtered by the query parameters
+//	POST   /pineapples       create
+//	GET    /pineapples/{id}  get
+//	PUT    /pineapples/{id}  update
+//	DELETE /pineapples/{id}  soft delete
+//
+// The store only hands out SafePineApple values, so the sensitive fields can be written but never read back.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	pineapple "github.com/corentings/goTeaching/goroutines_simple_vs_complex"
+	"github.com/corentings/goTeaching/goroutines_simple_vs_complex/store"
+)
+
+// MaxBodySize is the largest request body accepted, in bytes
+const MaxBodySize = 1 << 20
+
+// Server is the http.Handler of the pineapple API
+type Server struct {
+	repo store.Repository
+}
+
+// NewServer returns the API of the repository
+func NewServer(repo store.Repository) *Server {
+	return &Server{repo: repo}
+}
+
+// Input is the body of a create or an update, the other fields of Pineapple are set by the store
+type Input struct {
+	Paro       string
+	Turkey     string
+	Banana     string
+	Age        int
+	Size       int
+	SecretCode []byte // base64 in JSON
+}
+
+// List is the body of a list response, Next is the cursor of the next page
+type List struct {
+	Items []pineapple.SafePineApple `json:"items"`
+	Next  string                    `json:"next,omitempty"`
+}
+
+// Error is the body of an error response, Violations lists the broken validation rules
+type Error struct {
+	Error      string      `json:"error"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Violation is a validation rule broken by the input
+type Violation struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+}
+
+// ServeHTTP routes the request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == "/pineapples":
+		switch r.Method {
+		case http.MethodGet:
+			s.list(w, r)
+		case http.MethodPost:
+			s.create(w, r)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		}
+
+	case strings.HasPrefix(path, "/pineapples/"):
+		id, err := strconv.ParseUint(strings.TrimPrefix(path, "/pineapples/"), 10, 0)
+		if err != nil || id == 0 {
+			writeError(w, http.StatusNotFound, fmt.Errorf("%w: %q", store.ErrNotFound, strings.TrimPrefix(path, "/pineapples/")))
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			s.get(w, r, uint(id))
+		case http.MethodPut:
+			s.update(w, r, uint(id))
+		case http.MethodDelete:
+			s.delete(w, r, uint(id))
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+		}
+
+	default:
+		writeError(w, http.StatusNotFound, errors.New("unknown path "+r.URL.Path))
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := s.repo.List(r.Context(), q)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if page.Items == nil {
+		page.Items = []pineapple.SafePineApple{} // [] rather than null
+	}
+	writeJSON(w, http.StatusOK, List{Items: page.Items, Next: page.Next})
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, id uint) {
+	p, err := s.repo.Get(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	input, ok := readInput(w, r)
+	if !ok {
+		return
+	}
+
+	p, err := s.repo.Create(r.Context(), input.pineapple(0))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/pineapples/%d", p.ID))
+	writeJSON(w, http.StatusCreated, p)
+}
+
+func (s *Server) update(w http.ResponseWriter, r *http.Request, id uint) {
+	input, ok := readInput(w, r)
+	if !ok {
+		return
+	}
+
+	p, err := s.repo.Update(r.Context(), input.pineapple(id))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request, id uint) {
+	if err := s.repo.Delete(r.Context(), id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (in *Input) pineapple(id uint) pineapple.Pineapple {
+	return pineapple.Pineapple{ID: id, Paro: in.Paro, Turkey: in.Turkey, Banana: in.Banana, Age: in.Age, Size: in.Size, SecretCode: in.SecretCode}
+}
+
+// readInput decodes the body, or writes the error and returns false
+func readInput(w http.ResponseWriter, r *http.Request) (Input, bool) {
+	var input Input
+	if contentType := r.Header.Get("Content-Type"); contentType != "" && !strings.HasPrefix(contentType, "application/json") {
+		writeError(w, http.StatusUnsupportedMediaType, errors.New("expected application/json, got "+contentType))
+		return input, false
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, err)
+		} else {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		}
+		return input, false
+	}
+	return input, true
+}
+
+// parseQuery reads the filters, the order and the page from the query parameters:
+// name, min_age, max_age, created_after, created_before (RFC 3339), deleted, sort, desc, limit and cursor
+func parseQuery(values url.Values) (store.Query, error) {
+	q := store.Query{
+		Filter: store.Filter{Name: values.Get("name")},
+		Sort:   store.SortField(values.Get("sort")),
+		Cursor: values.Get("cursor"),
+	}
+
+	var err error
+	parseInt := func(key string) *int {
+		if err != nil || !values.Has(key) {
+			return nil
+		}
+		var i int
+		if i, err = strconv.Atoi(values.Get(key)); err != nil {
+			err = fmt.Errorf("%s: %w", key, err)
+		}
+		return &i
+	}
+	parseTime := func(key string) (t time.Time) {
+		if err == nil && values.Has(key) {
+			if t, err = time.Parse(time.RFC3339, values.Get(key)); err != nil {
+				err = fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		return t
+	}
+	parseBool := func(key string) (b bool) {
+		if err == nil && values.Has(key) {
+			if b, err = strconv.ParseBool(values.Get(key)); err != nil {
+				err = fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		return b
+	}
+
+	q.MinAge = parseInt("min_age")
+	q.MaxAge = parseInt("max_age")
+	q.CreatedAfter = parseTime("created_after")
+	q.CreatedBefore = parseTime("created_before")
+	q.IncludeDeleted = parseBool("deleted")
+	q.Desc = parseBool("desc")
+	if limit := parseInt("limit"); limit != nil {
+		q.Limit = *limit
+	}
+	return q, err
+}
+
+// writeStoreError maps the errors of the store to their status
+func writeStoreError(w http.ResponseWriter, err error) {
+	var report pineapple.ValidationReport
+	switch {
+	case errors.As(err, &report):
+		body := Error{Error: "invalid pineapple", Violations: make([]Violation, len(report))}
+		for i, v := range report {
+			body.Violations[i] = Violation{Field: v.Field, Rule: v.Rule}
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, body)
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, store.ErrExists):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, store.ErrInvalidQuery), errors.Is(err, store.ErrInvalidCursor):
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		writeError(w, http.StatusInternalServerError, errors.New(http.StatusText(http.StatusInternalServerError)))
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, Error{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
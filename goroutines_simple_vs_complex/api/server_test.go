@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	pineapple "github.com/corentings/goTeaching/goroutines_simple_vs_complex"
+	"github.com/corentings/goTeaching/goroutines_simple_vs_complex/store"
+)
+
+// do sends the request to the handler and decodes the JSON response into v when it is not nil
+func do(t *testing.T, handler http.Handler, method, target, body string, v any) *http.Response {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	request := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	response := recorder.Result()
+	if v != nil {
+		if err := json.NewDecoder(response.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: invalid JSON response: %v", method, target, err)
+		}
+	}
+	return response
+}
+
+func newTestServer(t *testing.T, n int) *Server {
+	t.Helper()
+
+	repo := store.NewMemoryRepository()
+	for i := 1; i <= n; i++ {
+		p := pineapple.Pineapple{Paro: fmt.Sprintf("pine-%02d", i), Turkey: "turkey", Banana: "banana", Age: i % 5, SecretCode: []byte("s3cr3t")}
+		if _, err := repo.Create(context.Background(), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewServer(repo)
+}
+
+func TestServerCRUD(t *testing.T) {
+	server := newTestServer(t, 0)
+
+	var created pineapple.SafePineApple
+	response := do(t, server, http.MethodPost, "/pineapples", `{"Paro":"paro","Turkey":"turkey","Banana":"banana","Age":3,"Size":12,"SecretCode":"czNjcjN0"}`, &created)
+	expected := pineapple.SafePineApple{Paro: "paro", Turkey: "turkey", Banana: "banana", Age: 3, IsAlive: true, ID: 1}
+	if response.StatusCode != http.StatusCreated || created != expected {
+		t.Fatalf("create: %d %v expected %v", response.StatusCode, created, expected)
+	}
+	if location := response.Header.Get("Location"); location != "/pineapples/1" {
+		t.Errorf("location %q", location)
+	}
+
+	var got pineapple.SafePineApple
+	if response := do(t, server, http.MethodGet, "/pineapples/1", "", &got); response.StatusCode != http.StatusOK || got != expected {
+		t.Errorf("get: %d %v expected %v", response.StatusCode, got, expected)
+	}
+
+	expected.Age = 4
+	var updated pineapple.SafePineApple
+	if response := do(t, server, http.MethodPut, "/pineapples/1", `{"Paro":"paro","Turkey":"turkey","Banana":"banana","Age":4}`, &updated); response.StatusCode != http.StatusOK || updated != expected {
+		t.Errorf("update: %d %v expected %v", response.StatusCode, updated, expected)
+	}
+
+	if response := do(t, server, http.MethodDelete, "/pineapples/1", "", nil); response.StatusCode != http.StatusNoContent {
+		t.Errorf("delete: %d", response.StatusCode)
+	}
+	if response := do(t, server, http.MethodGet, "/pineapples/1", "", nil); response.StatusCode != http.StatusNotFound {
+		t.Errorf("get after delete: %d", response.StatusCode)
+	}
+}
+
+func TestServerNeverReturnsSecrets(t *testing.T) {
+	server := newTestServer(t, 3)
+
+	for _, target := range []string{"/pineapples", "/pineapples?deleted=true", "/pineapples/2"} {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		for _, secret := range []string{"s3cr3t", "czNjcjN0", "SecretCode", "Size", "Created", "Updated"} {
+			if strings.Contains(recorder.Body.String(), secret) {
+				t.Errorf("%s leaks %s: %s", target, secret, recorder.Body)
+			}
+		}
+	}
+}
+
+func TestServerList(t *testing.T) {
+	server := newTestServer(t, 12)
+	do(t, server, http.MethodDelete, "/pineapples/7", "", nil)
+
+	tests := []struct {
+		target   string
+		expected []uint
+		next     bool
+	}{
+		{"/pineapples?limit=3", []uint{1, 2, 3}, true},
+		{"/pineapples?name=PINE-1", []uint{10, 11, 12}, false},
+		{"/pineapples?min_age=2&max_age=3&sort=age&desc=true", []uint{8, 3, 12, 2}, false},
+		{"/pineapples?min_age=2&max_age=2&deleted=true", []uint{2, 7, 12}, false},
+		{"/pineapples?name=nothing", []uint{}, false},
+		{"/pineapples?created_before=2000-01-01T00:00:00Z", []uint{}, false},
+	}
+	for _, test := range tests {
+		var list List
+		response := do(t, server, http.MethodGet, test.target, "", &list)
+		if response.StatusCode != http.StatusOK {
+			t.Errorf("%s: status %d", test.target, response.StatusCode)
+			continue
+		}
+		actual := make([]uint, len(list.Items))
+		for i, item := range list.Items {
+			actual[i] = item.ID
+		}
+		if !reflect.DeepEqual(actual, test.expected) || (list.Next != "") != test.next {
+			t.Errorf("%s: %v next %q expected %v", test.target, actual, list.Next, test.expected)
+		}
+	}
+
+	// Walk every page
+	var all []pineapple.SafePineApple
+	target := "/pineapples?limit=5&sort=name&desc=true"
+	for {
+		var list List
+		do(t, server, http.MethodGet, target, "", &list)
+		all = append(all, list.Items...)
+		if list.Next == "" {
+			break
+		}
+		target = "/pineapples?limit=5&sort=name&desc=true&cursor=" + list.Next
+	}
+	if len(all) != 11 || all[0].ID != 12 || all[10].ID != 1 {
+		t.Errorf("pages %v", all)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	server := newTestServer(t, 1)
+
+	tests := []struct {
+		method, target, body string
+		status               int
+	}{
+		{http.MethodGet, "/pineapples/42", "", http.StatusNotFound},
+		{http.MethodGet, "/pineapples/abc", "", http.StatusNotFound},
+		{http.MethodGet, "/pineapples/0", "", http.StatusNotFound},
+		{http.MethodGet, "/bananas", "", http.StatusNotFound},
+		{http.MethodDelete, "/pineapples/42", "", http.StatusNotFound},
+		{http.MethodPut, "/pineapples/42", `{"Paro":"p","Turkey":"t","Banana":"b"}`, http.StatusNotFound},
+		{http.MethodPatch, "/pineapples/1", "", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/pineapples", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/pineapples?min_age=old", "", http.StatusBadRequest},
+		{http.MethodGet, "/pineapples?created_after=yesterday", "", http.StatusBadRequest},
+		{http.MethodGet, "/pineapples?desc=maybe", "", http.StatusBadRequest},
+		{http.MethodGet, "/pineapples?sort=color", "", http.StatusBadRequest},
+		{http.MethodGet, "/pineapples?limit=-1", "", http.StatusBadRequest},
+		{http.MethodGet, "/pineapples?cursor=garbage", "", http.StatusBadRequest},
+		{http.MethodPost, "/pineapples", `{"Paro":`, http.StatusBadRequest},
+		{http.MethodPost, "/pineapples", `{"Paro":"p","Turkey":"t","Banana":"b","ID":3}`, http.StatusBadRequest},
+		{http.MethodPost, "/pineapples", `{"Paro":"p","Turkey":"t","Banana":"b","IsAlive":false}`, http.StatusBadRequest},
+		{http.MethodPost, "/pineapples", `{"Paro":"` + strings.Repeat("p", MaxBodySize) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, test := range tests {
+		var body Error
+		response := do(t, server, test.method, test.target, test.body, &body)
+		if response.StatusCode != test.status || body.Error == "" {
+			t.Errorf("%s %s: status %d %q expected %d", test.method, test.target, response.StatusCode, body.Error, test.status)
+		}
+	}
+
+	if response := do(t, server, http.MethodPatch, "/pineapples/1", "", &Error{}); response.Header.Get("Allow") != "GET, PUT, DELETE" {
+		t.Errorf("allow %q", response.Header.Get("Allow"))
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/pineapples", strings.NewReader(`Paro=p`))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("form body: status %d", recorder.Code)
+	}
+
+	var body Error
+	response := do(t, server, http.MethodPost, "/pineapples", `{"Paro":"","Turkey":"t","Banana":"b","Age":-1}`, &body)
+	expected := []Violation{{"Paro", pineapple.RuleNotEmpty}, {"Age", pineapple.RuleNonNegative}}
+	if response.StatusCode != http.StatusUnprocessableEntity || !reflect.DeepEqual(body.Violations, expected) {
+		t.Errorf("invalid pineapple: %d %v expected %v", response.StatusCode, body.Violations, expected)
+	}
+}
+
+// failingRepository fails every call
+type failingRepository struct{ store.Repository }
+
+func (failingRepository) Get(context.Context, uint) (pineapple.SafePineApple, error) {
+	return pineapple.SafePineApple{}, errors.New("connection refused to db.internal:5432")
+}
+
+func TestServerHidesInternalErrors(t *testing.T) {
+	var body Error
+	response := do(t, NewServer(failingRepository{}), http.MethodGet, "/pineapples/1", "", &body)
+	if response.StatusCode != http.StatusInternalServerError || strings.Contains(body.Error, "db.internal") {
+		t.Errorf("status %d error %q", response.StatusCode, body.Error)
+	}
+}
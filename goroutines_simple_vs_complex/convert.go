@@ -1,76 +1,31 @@
 package goroutines_simple_vs_complex
 
-import "sync"
+//go:generate go run ../benchmarks/cmd/benchchart -input benchmark.txt -o benchmark.svg -title "Pineapple conversion: time per conversion"
 
-func SimpleConvertPineApplesToSafety(pineapples []Pineapple) []SafePineApple {
-	safePineApples := make([]SafePineApple, len(pineapples))
+import "context"
 
-	for idx, pineapple := range pineapples {
-		safePineApples[idx] = pineapple.ToSafePineApple()
-	}
+// toSafePineApple adapts ToSafePineApple to ParallelMap, the conversion can't fail
+func toSafePineApple(pineapple Pineapple) (SafePineApple, error) {
+	return pineapple.ToSafePineApple(), nil
+}
 
+// SimpleConvertPineApplesToSafety converts the pineapples in a simple loop, ParallelMap doesn't spawn any goroutine for a single worker
+func SimpleConvertPineApplesToSafety(pineapples []Pineapple) []SafePineApple {
+	safePineApples, _ := ParallelMap(context.Background(), pineapples, toSafePineApple, MapOptions{Workers: 1})
 	return safePineApples
 }
 
+// GoroutinesConvertPineApplesToSafety converts each half of the pineapples in its own goroutine.
+// It used to guard the output with a mutex, but every index is written by a single goroutine so ParallelMap doesn't need one.
 func GoroutinesConvertPineApplesToSafety(pineapples []Pineapple) []SafePineApple {
-	// Create a slice to store the SafePineApples
-	safePineApples := make([]SafePineApple, len(pineapples))
-
-	// Split the offers into chunks
-	chunks := [][]Pineapple{pineapples[:len(pineapples)/2], pineapples[len(pineapples)/2:]}
-
-	mutex := sync.Mutex{}
-
-	var wg sync.WaitGroup
-	wg.Add(1)
-
-	go func(chunk []Pineapple) {
-		defer wg.Done()
-		for idx, pineapple := range chunk {
-			mutex.Lock()
-
-			safePineApples[idx] = pineapple.ToSafePineApple()
-
-			mutex.Unlock()
-		}
-	}(chunks[0])
-
-	for idx, pineapple := range chunks[1] {
-		mutex.Lock()
-		safePineApples[idx+len(chunks[0])] = pineapple.ToSafePineApple()
-		mutex.Unlock()
-	}
-
-	// Wait for all goroutines to finish
-	wg.Wait()
-
+	safePineApples, _ := ParallelMap(context.Background(), pineapples, toSafePineApple, MapOptions{Workers: 2})
 	return safePineApples
 }
 
+// GoroutinesNoMutexConvertPineApplesToSafety converts each half of the pineapples in its own goroutine.
+// It used to convert them into two slices appended at the end, ParallelMap writes straight into the output
+// so it is now the same as GoroutinesConvertPineApplesToSafety.
 func GoroutinesNoMutexConvertPineApplesToSafety(pineapples []Pineapple) []SafePineApple {
-	// Create a slice to store the SafePineApples
-	safePineApples := make([]SafePineApple, len(pineapples)/2, len(pineapples))
-	safePineApples2 := make([]SafePineApple, len(pineapples)/2)
-
-	var wg sync.WaitGroup
-	wg.Add(1)
-
-	go func(chunk []Pineapple) {
-		defer wg.Done()
-		for idx, pineapple := range chunk {
-			safePineApples[idx] = pineapple.ToSafePineApple()
-		}
-	}(pineapples[:len(pineapples)/2])
-
-	for idx, pineapple := range pineapples[len(pineapples)/2:] {
-		safePineApples2[idx] = pineapple.ToSafePineApple()
-	}
-
-	// Wait for all goroutines to finish
-	wg.Wait()
-
-	// Group both pineapples
-	safePineApples = append(safePineApples, safePineApples2...)
-
+	safePineApples, _ := ParallelMap(context.Background(), pineapples, toSafePineApple, MapOptions{Workers: 2})
 	return safePineApples
 }
@@ -2,26 +2,14 @@ package goroutines_simple_vs_complex
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
-	"time"
-
-	"github.com/go-faker/faker/v4"
 )
 
 func Benchmark_SimpleConvertPineApplesToSafety(b *testing.B) {
 	for _, n := range []int{500, 1000, 2000, 5000, 10000} {
 		b.Run(fmt.Sprintf("Benchmark_SimpleConvertPineApplesToSafety-%d", n), func(b *testing.B) {
-			pineApples := make([]Pineapple, n)
-
-			var pine Pineapple
-
-			for i := 0; i < n; i++ {
-				_ = faker.FakeData(&pine)
-				pine.Created = time.Now().AddDate(0, 0, -i)
-				pine.ID = uint(i)
-				pine.IsAlive = true
-				pineApples[i] = pine
-			}
+			pineApples := fixtures(n)
 			for i := 0; i < b.N; i++ {
 				SimpleConvertPineApplesToSafety(pineApples)
 			}
@@ -32,17 +20,7 @@ func Benchmark_SimpleConvertPineApplesToSafety(b *testing.B) {
 func Benchmark_GoroutinesConvertPineApplesToSafety(b *testing.B) {
 	for _, n := range []int{500, 1000, 2000, 5000, 10000} {
 		b.Run(fmt.Sprintf("Benchmark_GoroutinesConvertPineApplesToSafety-%d", n), func(b *testing.B) {
-			pineApples := make([]Pineapple, n)
-
-			var pine Pineapple
-
-			for i := 0; i < n; i++ {
-				_ = faker.FakeData(&pine)
-				pine.Created = time.Now().AddDate(0, 0, -i)
-				pine.ID = uint(i)
-				pine.IsAlive = true
-				pineApples[i] = pine
-			}
+			pineApples := fixtures(n)
 			for i := 0; i < b.N; i++ {
 				GoroutinesConvertPineApplesToSafety(pineApples)
 			}
@@ -53,17 +31,7 @@ func Benchmark_GoroutinesConvertPineApplesToSafety(b *testing.B) {
 func Benchmark_NoMutexGoroutinesConvertPineApplesToSafety(b *testing.B) {
 	for _, n := range []int{500, 1000, 2000, 5000, 10000} {
 		b.Run(fmt.Sprintf("Benchmark_NoMutexGoroutinesConvertPineApplesToSafety-%d", n), func(b *testing.B) {
-			pineApples := make([]Pineapple, n)
-
-			var pine Pineapple
-
-			for i := 0; i < n; i++ {
-				_ = faker.FakeData(&pine)
-				pine.Created = time.Now().AddDate(0, 0, -i)
-				pine.ID = uint(i)
-				pine.IsAlive = true
-				pineApples[i] = pine
-			}
+			pineApples := fixtures(n)
 			for i := 0; i < b.N; i++ {
 				GoroutinesNoMutexConvertPineApplesToSafety(pineApples)
 			}
@@ -71,74 +39,38 @@ func Benchmark_NoMutexGoroutinesConvertPineApplesToSafety(b *testing.B) {
 	}
 }
 
-func Test_GoroutinesConvertPineApplesToSafety(t *testing.T) {
-	t.Run("TestGoroutinesConvertPineApplesToSafety", func(t *testing.T) {
-		pineApples := make([]Pineapple, 10000)
-
-		var pine Pineapple
+// testConverter checks that the converter keeps the order and the values of the golden pineapples
+func testConverter(t *testing.T, convert func([]Pineapple) []SafePineApple) {
+	pineApples := loadFixtures(t)
 
-		for i := 0; i < 10000; i++ {
-			_ = faker.FakeData(&pine)
-			pine.Created = time.Now().AddDate(0, 0, -i)
-			pine.ID = uint(i)
-			pine.IsAlive = true
-			pineApples[i] = pine
-		}
+	got := convert(pineApples)
+	if expected := ToSafePineAppleSlice(pineApples); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+}
 
-		got := GoroutinesConvertPineApplesToSafety(pineApples)
-		// Check order by Created field
-		for i := 0; i < len(got)-1; i++ {
-			if got[i].ID > got[i+1].ID {
-				t.Errorf("GoroutinesConvertPineApplesToSafety() = %v, want %v", got, pineApples)
-			}
-		}
-	})
+func Test_GoroutinesConvertPineApplesToSafety(t *testing.T) {
+	testConverter(t, GoroutinesConvertPineApplesToSafety)
 }
 
 func Test_GouroutinesNoMutexConvertPineApplesToSafety(t *testing.T) {
-	t.Run("TestGouroutinesNoMutexConvertPineApplesToSafety", func(t *testing.T) {
-		pineApples := make([]Pineapple, 10000)
-
-		var pine Pineapple
-
-		for i := 0; i < 10000; i++ {
-			_ = faker.FakeData(&pine)
-			pine.Created = time.Now().AddDate(0, 0, -i)
-			pine.ID = uint(i)
-			pine.IsAlive = true
-			pineApples[i] = pine
-		}
-
-		got := GoroutinesNoMutexConvertPineApplesToSafety(pineApples)
-		// Check order by Created field
-		for i := 0; i < len(got)-1; i++ {
-			if got[i].ID > got[i+1].ID {
-				t.Errorf("TestGouroutinesNoMutexConvertPineApplesToSafety() = %v, want %v", got, pineApples)
-			}
-		}
-	})
+	testConverter(t, GoroutinesNoMutexConvertPineApplesToSafety)
 }
 
 func Test_SimpleConvertPineApplesToSafety(t *testing.T) {
-	t.Run("TestSimpleConvertPineApplesToSafety", func(t *testing.T) {
-		pineApples := make([]Pineapple, 10000)
-
-		var pine Pineapple
-
-		for i := 0; i < 10000; i++ {
-			_ = faker.FakeData(&pine)
-			pine.Created = time.Now().AddDate(0, 0, -i)
-			pine.ID = uint(i)
-			pine.IsAlive = true
-			pineApples[i] = pine
-		}
+	testConverter(t, SimpleConvertPineApplesToSafety)
+}
 
-		got := SimpleConvertPineApplesToSafety(pineApples)
-		// Check order by Created field
-		for i := 0; i < len(got)-1; i++ {
-			if got[i].ID > got[i+1].ID {
-				t.Errorf("TestSimpleConvertPineApplesToSafety() = %v, want %v", got, pineApples)
-			}
+// Test_ConvertOddPineApples checks the converters splitting the pineapples in two halves when the second one is longer
+func Test_ConvertOddPineApples(t *testing.T) {
+	pineApples := loadFixtures(t)[:7]
+	expected := ToSafePineAppleSlice(pineApples)
+	for name, convert := range map[string]func([]Pineapple) []SafePineApple{
+		"mutex":    GoroutinesConvertPineApplesToSafety,
+		"no mutex": GoroutinesNoMutexConvertPineApplesToSafety,
+	} {
+		if got := convert(pineApples); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: got %v, want %v", name, got, expected)
 		}
-	})
+	}
 }
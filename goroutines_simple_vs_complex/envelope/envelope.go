@@ -0,0 +1,265 @@
+// Package envelope encrypts secrets with AES-256-GCM under a keyring of versioned keys.
+//
+// Every secret is encrypted with its own random data key, and the data key is encrypted with a key of the keyring.
+// Rotating the keyring only re-encrypts the data keys, never the secrets themselves.
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	pineapple "github.com/corentings/goTeaching/goroutines_simple_vs_complex"
+)
+
+var (
+	// ErrInvalidKey is returned for a key which is not 32 bytes long
+	ErrInvalidKey = errors.New("envelope: invalid key")
+	// ErrKeyVersion is returned when rotating to a version which is not after the current one, or retiring the current one
+	ErrKeyVersion = errors.New("envelope: invalid key version")
+	// ErrUnknownKey is returned for a secret sealed with a key the keyring doesn't have
+	ErrUnknownKey = errors.New("envelope: unknown key")
+	// ErrDecrypt is returned when a sealed secret was tampered with or sealed for other associated data
+	ErrDecrypt = errors.New("envelope: decryption failed")
+)
+
+// KeySize is the size of the keys, for AES-256
+const KeySize = 32
+
+// Layout of a sealed secret:
+//
+//	format (1) | key version (4) | nonce (12) and data key (32) sealed by the key | nonce (12) and secret sealed by the data key
+const (
+	format         = 1
+	headerSize     = 1 + 4
+	nonceSize      = 12
+	wrappedKeySize = nonceSize + KeySize + 16
+	overhead       = headerSize + wrappedKeySize + nonceSize + 16
+)
+
+// Keyring holds the versioned keys, secrets are sealed with the current one. It is safe for concurrent use.
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[uint32]cipher.AEAD
+	current uint32
+}
+
+// NewKeyring returns a keyring whose current key is key
+func NewKeyring(version uint32, key []byte) (*Keyring, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Keyring{keys: map[uint32]cipher.AEAD{version: aead}, current: version}, nil
+}
+
+// GenerateKey returns a random key
+func GenerateKey() []byte {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		panic(err) // crypto/rand doesn't fail on supported platforms
+	}
+	return key
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("%w: %d bytes, expected %d", ErrInvalidKey, len(key), KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Current returns the version of the current key
+func (k *Keyring) Current() uint32 {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+// Rotate adds the key and makes it the current one, the previous keys still open the secrets they sealed
+func (k *Keyring) Rotate(version uint32, key []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if version <= k.current {
+		return fmt.Errorf("%w: %d is not after %d", ErrKeyVersion, version, k.current)
+	}
+	k.keys[version] = aead
+	k.current = version
+	return nil
+}
+
+// Retire removes a previous key, once every secret it sealed was rewrapped
+func (k *Keyring) Retire(version uint32) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if version == k.current {
+		return fmt.Errorf("%w: %d is the current key", ErrKeyVersion, version)
+	}
+	if _, ok := k.keys[version]; !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownKey, version)
+	}
+	delete(k.keys, version)
+	return nil
+}
+
+func (k *Keyring) key(version uint32) (cipher.AEAD, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	aead, ok := k.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKey, version)
+	}
+	return aead, nil
+}
+
+func (k *Keyring) currentKey() (uint32, cipher.AEAD) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current, k.keys[k.current]
+}
+
+// Version returns the version of the key which sealed the secret
+func Version(sealed []byte) (uint32, error) {
+	if len(sealed) < overhead || sealed[0] != format {
+		return 0, ErrDecrypt
+	}
+	return binary.BigEndian.Uint32(sealed[1:headerSize]), nil
+}
+
+// Seal encrypts the secret with the current key. The associated data, such as the ID of the owner of the secret,
+// is authenticated but not stored: the secret only opens with the same data, so it can't be moved to another owner.
+func (k *Keyring) Seal(secret, associated []byte) ([]byte, error) {
+	dataKey := GenerateKey()
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := make([]byte, headerSize, overhead+len(secret))
+	sealed[0] = format
+	sealed, err = k.wrap(sealed, dataKey, associated)
+	if err != nil {
+		return nil, err
+	}
+	return seal(data, sealed, secret, []byte{format}, associated)
+}
+
+// Open decrypts a secret sealed with the same associated data
+func (k *Keyring) Open(sealed, associated []byte) ([]byte, error) {
+	data, err := k.unwrap(sealed, associated)
+	if err != nil {
+		return nil, err
+	}
+
+	body := sealed[headerSize+wrappedKeySize:]
+	secret, err := data.Open(nil, body[:nonceSize], body[nonceSize:], additional([]byte{format}, associated))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return secret, nil
+}
+
+// Rewrap seals the data key of the secret with the current key, the encrypted secret is left as it is.
+// A secret already sealed with the current key is returned unchanged.
+func (k *Keyring) Rewrap(sealed, associated []byte) ([]byte, error) {
+	version, err := Version(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if version == k.Current() {
+		return sealed, nil
+	}
+
+	dataKey, err := k.openDataKey(sealed, associated)
+	if err != nil {
+		return nil, err
+	}
+	rewrapped := make([]byte, headerSize, len(sealed))
+	rewrapped[0] = format
+	if rewrapped, err = k.wrap(rewrapped, dataKey, associated); err != nil {
+		return nil, err
+	}
+	return append(rewrapped, sealed[headerSize+wrappedKeySize:]...), nil
+}
+
+// Item is a sealed secret with its associated data
+type Item struct {
+	Sealed     []byte
+	Associated []byte
+}
+
+// RewrapAll rewraps the items in parallel with ParallelMap, in the same order.
+// The errors of the items are joined, and a failed item is left to nil.
+func (k *Keyring) RewrapAll(ctx context.Context, items []Item, opts pineapple.MapOptions) ([][]byte, error) {
+	return pineapple.ParallelMap(ctx, items, func(item Item) ([]byte, error) {
+		return k.Rewrap(item.Sealed, item.Associated)
+	}, opts)
+}
+
+// wrap writes the version of the current key after the format byte of dst, then the data key sealed with it
+func (k *Keyring) wrap(dst, dataKey, associated []byte) ([]byte, error) {
+	version, aead := k.currentKey()
+	binary.BigEndian.PutUint32(dst[1:headerSize], version)
+	return seal(aead, dst, dataKey, dst[:headerSize], associated)
+}
+
+// openDataKey returns the data key of the secret, the header is authenticated along with the associated data
+func (k *Keyring) openDataKey(sealed, associated []byte) ([]byte, error) {
+	version, err := Version(sealed)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := k.key(version)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := sealed[headerSize : headerSize+wrappedKeySize]
+	dataKey, err := aead.Open(nil, wrapped[:nonceSize], wrapped[nonceSize:], additional(sealed[:headerSize], associated))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return dataKey, nil
+}
+
+func (k *Keyring) unwrap(sealed, associated []byte) (cipher.AEAD, error) {
+	dataKey, err := k.openDataKey(sealed, associated)
+	if err != nil {
+		return nil, err
+	}
+	return newAEAD(dataKey)
+}
+
+// seal appends a random nonce and the sealed plaintext to dst
+func seal(aead cipher.AEAD, dst, plaintext, header, associated []byte) ([]byte, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	dst = append(dst, nonce...)
+	return aead.Seal(dst, nonce, plaintext, additional(header, associated)), nil
+}
+
+// additional returns the header followed by the associated data, the data is prefixed by its length
+// so that no header and data can be confused with another pair
+func additional(header, associated []byte) []byte {
+	ad := make([]byte, 0, len(header)+4+len(associated))
+	ad = append(ad, header...)
+	ad = binary.BigEndian.AppendUint32(ad, uint32(len(associated)))
+	return append(ad, associated...)
+}
@@ -0,0 +1,212 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	pineapple "github.com/corentings/goTeaching/goroutines_simple_vs_complex"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func newTestKeyring(t *testing.T) *Keyring {
+	t.Helper()
+	k, err := NewKeyring(1, testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestSealOpen(t *testing.T) {
+	k := newTestKeyring(t)
+
+	for _, secret := range [][]byte{nil, []byte("s"), []byte("s3cr3t-c0de"), bytes.Repeat([]byte{0xff}, 1000)} {
+		sealed, err := k.Seal(secret, []byte("id-1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sealed) != overhead+len(secret) {
+			t.Errorf("sealed %x for %q", sealed, secret)
+		}
+		// A shorter secret could appear in the random bytes by chance
+		if len(secret) >= 8 && bytes.Contains(sealed, secret) {
+			t.Errorf("sealed %x holds %q in clear", sealed, secret)
+		}
+		if version, _ := Version(sealed); version != 1 {
+			t.Errorf("version %d expected 1", version)
+		}
+
+		opened, err := k.Open(sealed, []byte("id-1"))
+		if err != nil || !bytes.Equal(opened, secret) {
+			t.Errorf("opened %q %v expected %q", opened, err, secret)
+		}
+	}
+
+	first, _ := k.Seal([]byte("secret"), nil)
+	second, _ := k.Seal([]byte("secret"), nil)
+	if bytes.Equal(first, second) {
+		t.Error("sealing twice gave the same bytes")
+	}
+}
+
+func TestTampering(t *testing.T) {
+	k := newTestKeyring(t)
+	sealed, err := k.Seal([]byte("s3cr3t-c0de"), []byte("id-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flipping any bit of any byte is detected
+	for i := range sealed {
+		for bit := 0; bit < 8; bit++ {
+			tampered := append([]byte(nil), sealed...)
+			tampered[i] ^= 1 << bit
+			if opened, err := k.Open(tampered, []byte("id-1")); err == nil {
+				t.Fatalf("byte %d bit %d: opened %q", i, bit, opened)
+			}
+		}
+	}
+
+	tests := []struct {
+		name       string
+		sealed     []byte
+		associated string
+		err        error
+	}{
+		{"other owner", sealed, "id-2", ErrDecrypt},
+		{"no owner", sealed, "", ErrDecrypt},
+		{"truncated", sealed[:len(sealed)-1], "id-1", ErrDecrypt},
+		{"too short", sealed[:overhead-1], "id-1", ErrDecrypt},
+		{"empty", nil, "id-1", ErrDecrypt},
+		{"appended", append(append([]byte(nil), sealed...), 0), "id-1", ErrDecrypt},
+	}
+	for _, test := range tests {
+		if _, err := k.Open(test.sealed, []byte(test.associated)); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v got %v", test.name, test.err, err)
+		}
+	}
+
+	// A data key can't be moved to another secret
+	other, _ := k.Seal([]byte("other secret"), []byte("id-1"))
+	spliced := append(append([]byte(nil), other[:headerSize+wrappedKeySize]...), sealed[headerSize+wrappedKeySize:]...)
+	if _, err := k.Open(spliced, []byte("id-1")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("spliced: expected %v got %v", ErrDecrypt, err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	k := newTestKeyring(t)
+	sealed, _ := k.Seal([]byte("s3cr3t"), []byte("id"))
+
+	if err := k.Rotate(1, testKey(2)); !errors.Is(err, ErrKeyVersion) {
+		t.Errorf("rotate to the current version: expected %v got %v", ErrKeyVersion, err)
+	}
+	if err := k.Rotate(2, []byte("short")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("short key: expected %v got %v", ErrInvalidKey, err)
+	}
+	if err := k.Rotate(2, testKey(2)); err != nil || k.Current() != 2 {
+		t.Fatalf("rotate: %v current %d", err, k.Current())
+	}
+
+	// The previous key still opens its secrets, new ones use the current key
+	if opened, err := k.Open(sealed, []byte("id")); err != nil || string(opened) != "s3cr3t" {
+		t.Errorf("opened %q %v", opened, err)
+	}
+	if fresh, _ := k.Seal([]byte("new"), nil); mustVersion(t, fresh) != 2 {
+		t.Error("a new secret is not sealed with the current key")
+	}
+
+	rewrapped, err := k.Rewrap(sealed, []byte("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mustVersion(t, rewrapped) != 2 || !bytes.Equal(rewrapped[headerSize+wrappedKeySize:], sealed[headerSize+wrappedKeySize:]) {
+		t.Errorf("rewrap should only change the key: %x\n%x", rewrapped, sealed)
+	}
+	if again, _ := k.Rewrap(rewrapped, []byte("id")); !bytes.Equal(again, rewrapped) {
+		t.Error("a secret sealed with the current key was rewrapped")
+	}
+	if _, err := k.Rewrap(sealed, []byte("other")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("rewrap for another owner: expected %v got %v", ErrDecrypt, err)
+	}
+
+	if err := k.Retire(2); !errors.Is(err, ErrKeyVersion) {
+		t.Errorf("retire the current key: expected %v got %v", ErrKeyVersion, err)
+	}
+	if err := k.Retire(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Open(sealed, []byte("id")); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("retired key: expected %v got %v", ErrUnknownKey, err)
+	}
+	if opened, err := k.Open(rewrapped, []byte("id")); err != nil || string(opened) != "s3cr3t" {
+		t.Errorf("opened %q %v after retiring the previous key", opened, err)
+	}
+}
+
+func mustVersion(t *testing.T, sealed []byte) uint32 {
+	t.Helper()
+	version, err := Version(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return version
+}
+
+func TestRewrapAll(t *testing.T) {
+	k := newTestKeyring(t)
+	items := make([]Item, 500)
+	for i := range items {
+		items[i].Associated = []byte(fmt.Sprint(i))
+		items[i].Sealed, _ = k.Seal([]byte(fmt.Sprintf("secret-%d", i)), items[i].Associated)
+	}
+	items[7].Associated = []byte("tampered")
+
+	if err := k.Rotate(2, testKey(2)); err != nil {
+		t.Fatal(err)
+	}
+	rewrapped, err := k.RewrapAll(context.Background(), items, pineapple.MapOptions{Workers: 4, ChunkSize: 16})
+	if !errors.Is(err, ErrDecrypt) || rewrapped[7] != nil {
+		t.Errorf("expected %v for item 7 got %v", ErrDecrypt, err)
+	}
+
+	for i, sealed := range rewrapped {
+		if i == 7 {
+			continue
+		}
+		opened, err := k.Open(sealed, items[i].Associated)
+		if err != nil || string(opened) != fmt.Sprintf("secret-%d", i) || mustVersion(t, sealed) != 2 {
+			t.Fatalf("item %d: opened %q %v", i, opened, err)
+		}
+	}
+}
+
+func TestKeyringConcurrentUse(t *testing.T) {
+	k := newTestKeyring(t)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for v := uint32(2); v < 50; v++ {
+			if err := k.Rotate(v, GenerateKey()); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		sealed, err := k.Seal([]byte("secret"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opened, err := k.Open(sealed, nil); err != nil || string(opened) != "secret" {
+			t.Fatalf("opened %q %v", opened, err)
+		}
+	}
+	<-done
+}
@@ -0,0 +1,161 @@
+package goroutines_simple_vs_complex
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+)
+
+var (
+	firstNames = []string{"Ada", "Alan", "Barbara", "Claude", "Donald", "Edsger", "Frances", "Grace", "John", "Ken", "Leslie", "Margaret", "Niklaus", "Radia", "Rob", "Tony"}
+	lastNames  = []string{"Allen", "Dijkstra", "Hamilton", "Hoare", "Hopper", "Kernighan", "Knuth", "Lamport", "Liskov", "Lovelace", "Perlman", "Pike", "Shannon", "Thompson", "Turing", "Wirth"}
+)
+
+// FixtureBuilder generates pineapples for the tests and the benchmarks.
+// The same seed and settings always give the same pineapples, and the first n of a larger batch are the batch of n.
+type FixtureBuilder struct {
+	seed                 int64
+	start                time.Time
+	span                 time.Duration
+	maxUpdate            time.Duration
+	aliveRatio           float64
+	minSecret, maxSecret int
+	maxAge, maxSize      int
+}
+
+// NewFixtureBuilder returns a builder of valid pineapples, alive, created over 2020 to 2022 and updated within 30 days,
+// with an age up to 100, a size up to 1000 and a secret code of 16 to 32 bytes
+func NewFixtureBuilder(seed int64) *FixtureBuilder {
+	return &FixtureBuilder{
+		seed:       seed,
+		start:      time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		span:       3 * 365 * 24 * time.Hour,
+		maxUpdate:  30 * 24 * time.Hour,
+		aliveRatio: 1,
+		minSecret:  16,
+		maxSecret:  32,
+		maxAge:     100,
+		maxSize:    1000,
+	}
+}
+
+// Created spreads the creation times uniformly over [start, start+span)
+func (b *FixtureBuilder) Created(start time.Time, span time.Duration) *FixtureBuilder {
+	b.start, b.span = start, span
+	return b
+}
+
+// Updated spreads the update times uniformly over [created, created+within)
+func (b *FixtureBuilder) Updated(within time.Duration) *FixtureBuilder {
+	b.maxUpdate = within
+	return b
+}
+
+// AliveRatio is the probability of a pineapple to be alive
+func (b *FixtureBuilder) AliveRatio(ratio float64) *FixtureBuilder {
+	b.aliveRatio = ratio
+	return b
+}
+
+// SecretSize is the range of the length of the secret codes, inclusive
+func (b *FixtureBuilder) SecretSize(shortest, longest int) *FixtureBuilder {
+	b.minSecret, b.maxSecret = shortest, longest
+	return b
+}
+
+// Build returns n pineapples with the IDs 1 to n
+func (b *FixtureBuilder) Build(n int) []Pineapple {
+	r := rand.New(rand.NewSource(b.seed))
+	name := func() string {
+		return firstNames[r.Intn(len(firstNames))] + " " + lastNames[r.Intn(len(lastNames))]
+	}
+	between := func(lo, hi int) int {
+		if hi <= lo {
+			return lo
+		}
+		return lo + r.Intn(hi-lo+1)
+	}
+	seconds := func(d time.Duration) time.Duration {
+		if d < time.Second {
+			return 0
+		}
+		return time.Duration(r.Int63n(int64(d/time.Second))) * time.Second
+	}
+
+	pineApples := make([]Pineapple, n)
+	for i := range pineApples {
+		p := &pineApples[i]
+		p.ID = uint(i + 1)
+		p.Paro, p.Turkey, p.Banana = name(), name(), name()
+		p.Age = between(0, b.maxAge)
+		p.Size = between(0, b.maxSize)
+		p.IsAlive = r.Float64() < b.aliveRatio
+		p.SecretCode = make([]byte, between(b.minSecret, b.maxSecret))
+		r.Read(p.SecretCode)
+		p.Created = b.start.Add(seconds(b.span))
+		p.Updated = p.Created.Add(seconds(b.maxUpdate))
+	}
+	return pineApples
+}
+
+// fixtureRecord is a Pineapple with every field encoded, unlike its MarshalJSON which redacts them
+type fixtureRecord struct {
+	ID         uint      `json:"id"`
+	Paro       string    `json:"paro"`
+	Turkey     string    `json:"turkey"`
+	Banana     string    `json:"banana"`
+	Age        int       `json:"age"`
+	Size       int       `json:"size"`
+	IsAlive    bool      `json:"is_alive"`
+	SecretCode []byte    `json:"secret_code"`
+	Created    time.Time `json:"created"`
+	Updated    time.Time `json:"updated"`
+}
+
+// WriteFixtures writes the pineapples as a JSON array, one pineapple per line, with all their fields
+func WriteFixtures(w io.Writer, pineApples []Pineapple) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("[")
+	for i := range pineApples {
+		p := &pineApples[i]
+		data, err := json.Marshal(fixtureRecord{p.ID, p.Paro, p.Turkey, p.Banana, p.Age, p.Size, p.IsAlive, p.SecretCode, p.Created, p.Updated})
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n\t")
+		bw.Write(data)
+	}
+	bw.WriteString("\n]\n")
+	return bw.Flush()
+}
+
+// ReadFixtures reads the pineapples written by WriteFixtures
+func ReadFixtures(r io.Reader) ([]Pineapple, error) {
+	var records []fixtureRecord
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, err
+	}
+
+	pineApples := make([]Pineapple, len(records))
+	for i, rec := range records {
+		pineApples[i] = Pineapple{ID: rec.ID, Paro: rec.Paro, Turkey: rec.Turkey, Banana: rec.Banana, Age: rec.Age, Size: rec.Size,
+			IsAlive: rec.IsAlive, SecretCode: rec.SecretCode, Created: rec.Created, Updated: rec.Updated}
+	}
+	return pineApples, nil
+}
+
+// LoadFixtures reads the pineapples of a file written by WriteFixtures
+func LoadFixtures(path string) ([]Pineapple, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadFixtures(f)
+}
@@ -0,0 +1,93 @@
+package goroutines_simple_vs_complex
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// goldenFixtures holds the pineapples shared by the tests, built by fixtures(100)
+const goldenFixtures = "testdata/pineapples.json"
+
+// fixtures returns the pineapples of the tests and the benchmarks, one in ten is dead
+func fixtures(n int) []Pineapple {
+	return NewFixtureBuilder(42).AliveRatio(0.9).Build(n)
+}
+
+// loadFixtures reads the golden pineapples
+func loadFixtures(t testing.TB) []Pineapple {
+	t.Helper()
+	pineApples, err := LoadFixtures(goldenFixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pineApples
+}
+
+// TestGoldenFixtures checks that the builder still gives the pineapples of the golden file, run with -update to rewrite it
+func TestGoldenFixtures(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteFixtures(&buf, fixtures(100)); err != nil {
+		t.Fatal(err)
+	}
+
+	if *update {
+		if err := os.WriteFile(goldenFixtures, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile(goldenFixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("%s is out of date, run go test -update", goldenFixtures)
+	}
+
+	if loaded := loadFixtures(t); !reflect.DeepEqual(loaded, fixtures(100)) {
+		t.Error("the golden pineapples don't read back as the built ones")
+	}
+}
+
+func TestFixtureBuilder(t *testing.T) {
+	if !reflect.DeepEqual(fixtures(50), fixtures(50)) {
+		t.Error("the same seed gave different pineapples")
+	}
+	if !reflect.DeepEqual(fixtures(1000)[:50], fixtures(50)) {
+		t.Error("a larger batch doesn't start with the smaller one")
+	}
+	if reflect.DeepEqual(NewFixtureBuilder(1).Build(50), NewFixtureBuilder(2).Build(50)) {
+		t.Error("different seeds gave the same pineapples")
+	}
+
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	pineApples := NewFixtureBuilder(7).Created(start, 24*time.Hour).Updated(time.Hour).AliveRatio(0.25).SecretSize(4, 8).Build(10000)
+
+	alive := 0
+	for i, p := range pineApples {
+		if p.ID != uint(i+1) {
+			t.Fatalf("pineapple %d has the ID %d", i, p.ID)
+		}
+		if p.Created.Before(start) || !p.Created.Before(start.Add(24*time.Hour)) || p.Updated.Before(p.Created) || !p.Updated.Before(p.Created.Add(time.Hour)) {
+			t.Fatalf("pineapple %d created %v updated %v", i, p.Created, p.Updated)
+		}
+		if len(p.SecretCode) < 4 || len(p.SecretCode) > 8 {
+			t.Fatalf("pineapple %d has a secret of %d bytes", i, len(p.SecretCode))
+		}
+		if p.IsAlive {
+			alive++
+		}
+	}
+	if alive < 2300 || alive > 2700 {
+		t.Errorf("%d alive pineapples out of 10000 expected about 2500", alive)
+	}
+
+	// The pineapples are valid whatever their status
+	if _, report, _ := ConvertValidPineApplesToSafety(context.Background(), pineApples, MapOptions{Workers: 1}); len(report) != 0 {
+		t.Errorf("invalid fixtures %v", report[:1])
+	}
+}
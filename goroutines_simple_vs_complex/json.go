@@ -0,0 +1,23 @@
+package goroutines_simple_vs_complex
+
+import "github.com/corentings/goTeaching/redact"
+
+// PineapplePolicies redact the fields of a Pineapple encoded in JSON, for the whole process.
+// The fields without a policy follow their safe tag, so the sensitive fields are omitted unless a policy says otherwise.
+// A test changing them should restore a Snapshot when it is done:
+//
+//	PineapplePolicies.Set("SecretCode", redact.MaskLast4)
+var PineapplePolicies = redact.NewPolicies(nil)
+
+// SafePineApplePolicies redact the fields of a SafePineApple encoded in JSON, all of them are kept by default
+var SafePineApplePolicies = redact.NewPolicies(nil)
+
+// MarshalJSON encodes the pineapple with PineapplePolicies, so that logging or serving it can't leak its SecretCode
+func (p Pineapple) MarshalJSON() ([]byte, error) {
+	return redact.MarshalJSON(p, PineapplePolicies)
+}
+
+// MarshalJSON encodes the safe pineapple with SafePineApplePolicies
+func (p SafePineApple) MarshalJSON() ([]byte, error) {
+	return redact.MarshalJSON(p, SafePineApplePolicies)
+}
@@ -0,0 +1,111 @@
+package goroutines_simple_vs_complex
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/corentings/goTeaching/redact"
+)
+
+// setPolicy changes a policy for the duration of the test, the policies are then restored as the test found them
+func setPolicy(t testing.TB, policies *redact.Policies, field string, policy redact.Policy) {
+	snapshot := policies.Snapshot()
+	t.Cleanup(func() { policies.Restore(snapshot) })
+	policies.Set(field, policy)
+}
+
+func TestPineappleJSON(t *testing.T) {
+	pine := Pineapple{Paro: "paro", Turkey: "turkey", Banana: "banana", Age: 3, Size: 12, IsAlive: true, ID: 7,
+		SecretCode: []byte("s3cr3t-c0de"), Created: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	const safe = `{"Paro":"paro","Turkey":"turkey","Banana":"banana","Age":3,"IsAlive":true,"ID":7}`
+	for _, v := range []any{pine, &pine, []Pineapple{pine}} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if expected := safe; !bytes.Contains(data, []byte(expected)) || bytes.Contains(data, []byte("s3cr3t")) {
+			t.Errorf("encoded %s expected %s", data, expected)
+		}
+	}
+
+	// The JSON of a pineapple holds the fields of its safe counterpart, which declares IsAlive before Age
+	const safeCounterpart = `{"Paro":"paro","Turkey":"turkey","Banana":"banana","IsAlive":true,"Age":3,"ID":7}`
+	if data, _ := json.Marshal(pine.ToSafePineApple()); string(data) != safeCounterpart {
+		t.Errorf("safe pineapple %s expected %s", data, safeCounterpart)
+	}
+
+	t.Run("runtime policies", func(t *testing.T) {
+		setPolicy(t, PineapplePolicies, "SecretCode", redact.MaskLast4)
+		setPolicy(t, PineapplePolicies, "Created", redact.Keep)
+		setPolicy(t, PineapplePolicies, "Paro", redact.Truncate(2))
+		setPolicy(t, SafePineApplePolicies, "Banana", redact.Hash)
+
+		data, _ := json.Marshal(pine)
+		if expected := `{"Paro":"pa…","Turkey":"turkey","Banana":"banana","Age":3,"IsAlive":true,"ID":7,"SecretCode":"****c0de","Created":"2023-01-02T03:04:05Z"}`; string(data) != expected {
+			t.Errorf("actual   %s\nexpected %s", data, expected)
+		}
+
+		data, _ = json.Marshal(pine.ToSafePineApple())
+		if expected := `{"Paro":"paro","Turkey":"turkey","Banana":"` + redact.HashString("banana") + `","IsAlive":true,"Age":3,"ID":7}`; string(data) != expected {
+			t.Errorf("actual   %s\nexpected %s", data, expected)
+		}
+	})
+}
+
+// secretPolicies are the policies which must not let any byte of the secret through
+var secretPolicies = []redact.Policy{redact.Omit, redact.MaskAll, redact.Hash}
+
+func FuzzPineappleJSONNeverLeaksSecretCode(f *testing.F) {
+	f.Add([]byte("s3cr3t-c0de"), "paro", 42, uint8(0))
+	f.Add([]byte("correct horse battery staple"), "", -1, uint8(1))
+	f.Add([]byte{0xff, 0xfe, 0x00, '"', '\\', 0x10, 0x80, 0xc3}, " ", 0, uint8(2))
+	f.Add([]byte("paro-paro"), "paro-paro", 7, uint8(2))
+
+	f.Fuzz(func(t *testing.T, secret []byte, paro string, age int, policy uint8) {
+		setPolicy(t, PineapplePolicies, "SecretCode", secretPolicies[int(policy)%len(secretPolicies)])
+
+		pine := Pineapple{Paro: paro, Turkey: "turkey", Age: age, IsAlive: true, ID: 1, SecretCode: secret}
+		data, err := json.Marshal(pine)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !json.Valid(data) {
+			t.Fatalf("invalid JSON %s", data)
+		}
+
+		pine.SecretCode = nil
+		baseline, err := json.Marshal(pine)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		// Omitted and masked secrets must not change the output at all
+		if policy := secretPolicies[int(policy)%len(secretPolicies)]; policy != redact.Hash {
+			if !bytes.Equal(data, baseline) {
+				t.Fatalf("the secret changed the output with the %s policy:\n%s\n%s", policy, data, baseline)
+			}
+			return
+		}
+
+		// A hash is derived from the secret, but none of its encodings may appear
+		if len(secret) < 8 {
+			return // too short not to appear by chance in a hash or in the other fields
+		}
+		escaped, _ := json.Marshal(string(secret))
+		for _, encoding := range [][]byte{
+			secret,
+			escaped[1 : len(escaped)-1],
+			[]byte(base64.StdEncoding.EncodeToString(secret)),
+			[]byte(hex.EncodeToString(secret)),
+		} {
+			if bytes.Contains(data, encoding) && !bytes.Contains(baseline, encoding) {
+				t.Fatalf("secret %q leaked as %q in %s", secret, encoding, data)
+			}
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package goroutines_simple_vs_complex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// MapOptions configures ParallelMap
+type MapOptions struct {
+	Workers   int // number of goroutines, including the caller's one. runtime.NumCPU() when 0 or less
+	ChunkSize int // number of items a worker takes at once. The input is split evenly between the workers when 0 or less
+}
+
+// ParallelMap applies fn to every item of in and returns the results in the same order.
+//
+// Every output index is written by a single worker, so no mutex is needed.
+// The caller's goroutine works too: with one worker nothing is spawned and ParallelMap is a simple loop.
+// The errors of every item are joined, and the result of a failed item is left to its zero value.
+// Once ctx is done the workers stop taking new chunks and ctx.Err() is part of the returned error.
+func ParallelMap[In, Out any](ctx context.Context, in []In, fn func(In) (Out, error), opts MapOptions) ([]Out, error) {
+	out := make([]Out, len(in))
+	return out, parallelMapInto(ctx, in, out, 0, fn, opts)
+}
+
+// parallelMapInto is ParallelMap writing into out, the items are numbered from first in the errors
+func parallelMapInto[In, Out any](ctx context.Context, in []In, out []Out, first int, fn func(In) (Out, error), opts MapOptions) error {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = (len(in) + workers - 1) / workers
+	}
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+	chunks := (len(in) + chunkSize - 1) / chunkSize
+	if workers > chunks {
+		workers = chunks
+	}
+
+	errs := make([][]error, chunks) // per chunk, so that the errors are in the order of the items
+	var next int64                  // next chunk to take
+
+	work := func() {
+		for ctx.Err() == nil {
+			chunk := int(atomic.AddInt64(&next, 1) - 1)
+			if chunk >= chunks {
+				return
+			}
+
+			start, end := chunk*chunkSize, (chunk+1)*chunkSize
+			if end > len(in) {
+				end = len(in)
+			}
+			for i := start; i < end; i++ {
+				result, err := fn(in[i])
+				if err != nil {
+					errs[chunk] = append(errs[chunk], fmt.Errorf("item %d: %w", first+i, err))
+					continue
+				}
+				out[i] = result
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for w := 1; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work()
+		}()
+	}
+	work()
+	wg.Wait()
+
+	var all []error
+	if int(atomic.LoadInt64(&next)) < chunks {
+		all = append(all, ctx.Err()) // some chunks were never taken
+	}
+	for _, chunkErrs := range errs {
+		all = append(all, chunkErrs...)
+	}
+
+	return errors.Join(all...)
+}
@@ -0,0 +1,130 @@
+package goroutines_simple_vs_complex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallelMap(t *testing.T) {
+	for _, n := range []int{0, 1, 7, 100, 1001} {
+		in := make([]int, n)
+		expected := make([]string, n)
+		for i := range in {
+			in[i] = i
+			expected[i] = strconv.Itoa(i * i)
+		}
+
+		for _, opts := range []MapOptions{{}, {Workers: 1}, {Workers: 2}, {Workers: 4, ChunkSize: 3}, {Workers: 16, ChunkSize: 1}, {Workers: 3, ChunkSize: 5000}} {
+			t.Run(fmt.Sprintf("%d/%+v", n, opts), func(t *testing.T) {
+				actual, err := ParallelMap(context.Background(), in, func(i int) (string, error) {
+					return strconv.Itoa(i * i), nil
+				}, opts)
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				if !reflect.DeepEqual(actual, expected) {
+					t.Errorf("actual %v expected %v", actual, expected)
+				}
+			})
+		}
+	}
+}
+
+func TestParallelMapErrors(t *testing.T) {
+	errOdd := errors.New("odd")
+	errSeven := errors.New("seven")
+
+	in := []int{0, 1, 2, 3, 4, 5, 6, 7, 8}
+	out, err := ParallelMap(context.Background(), in, func(i int) (int, error) {
+		switch {
+		case i == 7:
+			return 0, errSeven
+		case i%2 == 1:
+			return 0, errOdd
+		}
+		return i * 10, nil
+	}, MapOptions{Workers: 3, ChunkSize: 2})
+
+	if !errors.Is(err, errOdd) || !errors.Is(err, errSeven) {
+		t.Fatalf("expected both errors joined, got %v", err)
+	}
+	if expected := "item 1: odd\nitem 3: odd\nitem 5: odd\nitem 7: seven"; err.Error() != expected {
+		t.Errorf("error %q expected the errors in the order of the items %q", err, expected)
+	}
+	if expected := []int{0, 0, 20, 0, 40, 0, 60, 0, 80}; !reflect.DeepEqual(out, expected) {
+		t.Errorf("actual %v expected %v", out, expected)
+	}
+}
+
+func TestParallelMapCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls int64
+	in := make([]int, 1000)
+	_, err := ParallelMap(ctx, in, func(i int) (int, error) {
+		if atomic.AddInt64(&calls, 1) == 10 {
+			cancel()
+		}
+		return i, nil
+	}, MapOptions{Workers: 2, ChunkSize: 10})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v got %v", context.Canceled, err)
+	}
+	if n := atomic.LoadInt64(&calls); n >= 1000 {
+		t.Errorf("%d items mapped after the cancellation, expected the workers to stop", n)
+	}
+
+	// A context cancelled after the last chunk is not an error
+	ctx, cancel = context.WithCancel(context.Background())
+	if _, err := ParallelMap(ctx, []int{1, 2}, func(i int) (int, error) { cancel(); return i, nil }, MapOptions{Workers: 1}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestParallelMapNoGoroutineLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		_, _ = ParallelMap(context.Background(), make([]int, 100), func(i int) (int, error) { return i, nil }, MapOptions{Workers: 8, ChunkSize: 1})
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines before, %d after", before, after)
+	}
+}
+
+func TestConvertersMatchToSafePineApple(t *testing.T) {
+	pineApples := make([]Pineapple, 1001)
+	for i := range pineApples {
+		pineApples[i] = Pineapple{Paro: strconv.Itoa(i), Age: i, ID: uint(i), IsAlive: i%2 == 0, SecretCode: []byte("secret")}
+	}
+
+	for name, convert := range map[string]func([]Pineapple) []SafePineApple{
+		"simple":     SimpleConvertPineApplesToSafety,
+		"goroutines": GoroutinesConvertPineApplesToSafety,
+		"no mutex":   GoroutinesNoMutexConvertPineApplesToSafety,
+	} {
+		got := convert(pineApples)
+		if len(got) != len(pineApples) {
+			t.Fatalf("%s: %d pineapples expected %d", name, len(got), len(pineApples))
+		}
+		for i := range pineApples {
+			if got[i] != pineApples[i].ToSafePineApple() {
+				t.Errorf("%s: pineapple %d is %+v expected %+v", name, i, got[i], pineApples[i].ToSafePineApple())
+				break
+			}
+		}
+	}
+}
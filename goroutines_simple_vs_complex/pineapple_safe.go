@@ -0,0 +1,34 @@
+// Code generated by safegen from Pineapple; DO NOT EDIT.
+
+package goroutines_simple_vs_complex
+
+// SafePineApple is a Pineapple without its sensitive fields
+type SafePineApple struct {
+	Paro    string
+	Turkey  string
+	Banana  string
+	IsAlive bool
+	Age     int
+	ID      uint
+}
+
+// ToSafePineApple converts a Pineapple to a SafePineApple
+func (p *Pineapple) ToSafePineApple() SafePineApple {
+	return SafePineApple{
+		Paro:    p.Paro,
+		Turkey:  p.Turkey,
+		Banana:  p.Banana,
+		IsAlive: p.IsAlive,
+		Age:     p.Age,
+		ID:      p.ID,
+	}
+}
+
+// ToSafePineAppleSlice converts every Pineapple to a SafePineApple, keeping their order
+func ToSafePineAppleSlice(items []Pineapple) []SafePineApple {
+	safeItems := make([]SafePineApple, len(items))
+	for i := range items {
+		safeItems[i] = items[i].ToSafePineApple()
+	}
+	return safeItems
+}
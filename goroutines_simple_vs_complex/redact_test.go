@@ -0,0 +1,48 @@
+package goroutines_simple_vs_complex
+
+import (
+	"testing"
+
+	"github.com/corentings/goTeaching/redact"
+)
+
+func TestRedactMatchesToSafePineApple(t *testing.T) {
+	for _, pine := range loadFixtures(t) {
+		var safe SafePineApple
+		if err := redact.Into(&safe, pine); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if expected := pine.ToSafePineApple(); safe != expected {
+			t.Fatalf("redacted %+v expected %+v", safe, expected)
+		}
+
+		fields, err := redact.ToMap(&pine)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		for _, omitted := range []string{"Size", "SecretCode", "Created", "Updated"} {
+			if _, ok := fields[omitted]; ok {
+				t.Fatalf("%s leaked in %v", omitted, fields)
+			}
+		}
+		if len(fields) != 6 || fields["Paro"] != pine.Paro || fields["ID"] != pine.ID {
+			t.Fatalf("unexpected safe fields %v", fields)
+		}
+	}
+}
+
+func Benchmark_RedactPineApple(b *testing.B) {
+	pine := fixtures(1)[0]
+
+	b.Run("ToSafePineApple", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = pine.ToSafePineApple()
+		}
+	})
+	b.Run("redact.Into", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var safe SafePineApple
+			_ = redact.Into(&safe, &pine)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package goroutines_simple_vs_complex
+
+import (
+	"context"
+
+	"github.com/corentings/goTeaching/redact"
+)
+
+// Roles of the consumers of pineapples
+const (
+	RoleAdmin   redact.Role = "admin"   // sees everything
+	RoleAuditor redact.Role = "auditor" // sees the public fields and the timestamps
+	RolePublic  redact.Role = "public"  // sees the fields of SafePineApple
+)
+
+// publicFields are the fields of SafePineApple
+var publicFields = []string{"Paro", "Turkey", "Banana", "Age", "IsAlive", "ID"}
+
+// NewPineappleRoles returns a new copy of the default views of a Pineapple for each role
+func NewPineappleRoles() *redact.RoleViews {
+	return redact.NewRoleViews(map[redact.Role][]string{
+		RoleAdmin:   append([]string{"Size", "SecretCode", "Created", "Updated"}, publicFields...),
+		RoleAuditor: append([]string{"Created", "Updated"}, publicFields...),
+		RolePublic:  publicFields,
+	})
+}
+
+// PineappleRoles are the fields of a Pineapple visible to each role for the whole process, they can be changed at runtime:
+//
+//	PineappleRoles.Allow(RoleAuditor, "SecretCode", redact.Hash)
+//
+// Tests should change a copy from NewPineappleRoles instead.
+var PineappleRoles = NewPineappleRoles()
+
+// View returns the fields of the pineapple the role may see, by field name
+func (p *Pineapple) View(role redact.Role) (map[string]any, error) {
+	return PineappleRoles.View(role, p)
+}
+
+// ViewPineApples returns the view of every pineapple for the role, in the same order, using ParallelMap
+func ViewPineApples(ctx context.Context, role redact.Role, pineapples []Pineapple, opts MapOptions) ([]map[string]any, error) {
+	// An unknown role would fail on every pineapple, fail once instead
+	if _, err := PineappleRoles.View(role, &Pineapple{}); err != nil {
+		return nil, err
+	}
+
+	return ParallelMap(ctx, pineapples, func(p Pineapple) (map[string]any, error) {
+		return p.View(role)
+	}, opts)
+}
@@ -0,0 +1,129 @@
+package goroutines_simple_vs_complex
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/corentings/goTeaching/redact"
+)
+
+func testPineapple(id int) Pineapple {
+	created := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	return Pineapple{Paro: "paro", Turkey: "turkey", Banana: "banana", Age: 3, Size: 12, IsAlive: true, ID: uint(id),
+		SecretCode: []byte("s3cr3t"), Created: created, Updated: created.Add(time.Hour)}
+}
+
+func TestPineappleViews(t *testing.T) {
+	pine := testPineapple(7)
+	public := map[string]any{"Paro": "paro", "Turkey": "turkey", "Banana": "banana", "Age": 3, "IsAlive": true, "ID": uint(7)}
+
+	with := func(fields map[string]any) map[string]any {
+		view := map[string]any{}
+		for k, v := range public {
+			view[k] = v
+		}
+		for k, v := range fields {
+			view[k] = v
+		}
+		return view
+	}
+
+	tests := []struct {
+		name     string
+		role     redact.Role
+		expected map[string]any
+		err      error
+	}{
+		{"admin sees everything", RoleAdmin, with(map[string]any{"Size": 12, "SecretCode": []byte("s3cr3t"), "Created": pine.Created, "Updated": pine.Updated}), nil},
+		{"auditor sees the timestamps", RoleAuditor, with(map[string]any{"Created": pine.Created, "Updated": pine.Updated}), nil},
+		{"public sees the safe pineapple", RolePublic, public, nil},
+		{"unknown role", "guest", nil, redact.ErrUnknownRole},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			view, err := pine.View(test.role)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v got %v", test.err, err)
+			}
+			if !reflect.DeepEqual(view, test.expected) {
+				t.Errorf("actual %v expected %v", view, test.expected)
+			}
+		})
+	}
+}
+
+// TestPublicViewIsSafePineApple checks the public view against ToSafePineApple, so that both can't drift apart
+func TestPublicViewIsSafePineApple(t *testing.T) {
+	pine := testPineapple(1)
+
+	view, err := pine.View(RolePublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	safe, err := redact.ToMap(pine.ToSafePineApple())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(view, safe) {
+		t.Errorf("public view %v expected %v", view, safe)
+	}
+}
+
+func TestPineappleViewsAtRuntime(t *testing.T) {
+	roles := NewPineappleRoles() // PineappleRoles is left untouched
+	roles.Allow(RoleAuditor, "SecretCode", redact.Hash)
+
+	pine := testPineapple(1)
+	view, err := roles.View(RoleAuditor, &pine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view["SecretCode"] != redact.HashString("s3cr3t") {
+		t.Errorf("auditor SecretCode %v expected its hash", view["SecretCode"])
+	}
+
+	if view, _ := pine.View(RoleAuditor); view["SecretCode"] != nil {
+		t.Errorf("PineappleRoles changed by a copy: auditor sees SecretCode %v", view["SecretCode"])
+	}
+}
+
+func TestPineappleViewCopiesSecretCode(t *testing.T) {
+	pine := testPineapple(1)
+	view, err := pine.View(RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	view["SecretCode"].([]byte)[0] = 'X'
+	if string(pine.SecretCode) != "s3cr3t" {
+		t.Errorf("changing the view changed the pineapple SecretCode to %s", pine.SecretCode)
+	}
+}
+
+func TestViewPineApples(t *testing.T) {
+	pineApples := make([]Pineapple, 1000)
+	for i := range pineApples {
+		pineApples[i] = testPineapple(i)
+	}
+
+	for _, role := range []redact.Role{RoleAdmin, RoleAuditor, RolePublic} {
+		views, err := ViewPineApples(context.Background(), role, pineApples, MapOptions{Workers: 4, ChunkSize: 64})
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", role, err)
+		}
+		for i, view := range views {
+			expected, _ := pineApples[i].View(role)
+			if !reflect.DeepEqual(view, expected) {
+				t.Fatalf("%s: view %d is %v expected %v", role, i, view, expected)
+			}
+		}
+	}
+
+	if _, err := ViewPineApples(context.Background(), "guest", pineApples, MapOptions{}); !errors.Is(err, redact.ErrUnknownRole) {
+		t.Errorf("expected %v got %v", redact.ErrUnknownRole, err)
+	}
+}
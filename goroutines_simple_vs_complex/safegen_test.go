@@ -0,0 +1,129 @@
+package goroutines_simple_vs_complex
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/corentings/goTeaching/redact/gen"
+)
+
+var update = flag.Bool("update", false, "regenerate pineapple_safe.go and the golden fixtures")
+
+// TestGeneratedSafePineApple checks that pineapple_safe.go is what safegen generates from the tags of Pineapple,
+// with the same configuration as the go:generate directive of utils.go
+func TestGeneratedSafePineApple(t *testing.T) {
+	actual, err := gen.GenerateDir(".", gen.Config{Type: "Pineapple", SafeType: "SafePineApple", KeepTags: []string{"json"},
+		Order: []string{"Paro", "Turkey", "Banana", "IsAlive", "Age"}})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if *update {
+		if err := os.WriteFile("pineapple_safe.go", actual, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile("pineapple_safe.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(actual) != string(expected) {
+		t.Errorf("pineapple_safe.go is out of date, run go generate:\n%s", actual)
+	}
+}
+
+// TestSafePineAppleLayout checks the generated SafePineApple and ToSafePineApple against the hand written code safegen replaced,
+// kept in testdata/safe_pineapple.golden, so that the order of the fields can't change
+func TestSafePineAppleLayout(t *testing.T) {
+	declarations := func(path string) map[string]string {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		printed := make(map[string]string)
+		print := func(name string, node any) {
+			var buf bytes.Buffer
+			if err := printer.Fprint(&buf, fset, node); err != nil {
+				t.Fatal(err)
+			}
+			printed[name] = buf.String()
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				print(decl.Name.Name, decl.Body)
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if spec, ok := spec.(*ast.TypeSpec); ok {
+						print(spec.Name.Name, spec.Type)
+					}
+				}
+			}
+		}
+		return printed
+	}
+
+	generated, handWritten := declarations("pineapple_safe.go"), declarations("testdata/safe_pineapple.golden")
+	for _, name := range []string{"SafePineApple", "ToSafePineApple"} {
+		if generated[name] != handWritten[name] {
+			t.Errorf("generated %s\n%s\nexpected\n%s", name, generated[name], handWritten[name])
+		}
+	}
+}
+
+// handWrittenSafePineApple and its conversion are the code safegen replaced
+type handWrittenSafePineApple struct {
+	Paro    string
+	Turkey  string
+	Banana  string
+	IsAlive bool
+	Age     int
+	ID      uint
+}
+
+func handWrittenToSafePineApple(p *Pineapple) handWrittenSafePineApple {
+	return handWrittenSafePineApple{
+		Paro:    p.Paro,
+		Turkey:  p.Turkey,
+		Banana:  p.Banana,
+		IsAlive: p.IsAlive,
+		Age:     p.Age,
+		ID:      p.ID,
+	}
+}
+
+// TestGeneratedMatchesHandWritten checks that the generated struct has the fields of the hand written one, and holds the same values
+func TestGeneratedMatchesHandWritten(t *testing.T) {
+	generated, handWritten := reflect.TypeOf(SafePineApple{}), reflect.TypeOf(handWrittenSafePineApple{})
+	if generated.NumField() != handWritten.NumField() {
+		t.Fatalf("%d generated fields expected %d", generated.NumField(), handWritten.NumField())
+	}
+	for i := 0; i < handWritten.NumField(); i++ {
+		expected := handWritten.Field(i)
+		if actual, ok := generated.FieldByName(expected.Name); !ok || actual.Type != expected.Type {
+			t.Errorf("generated field %s: %v expected %v", expected.Name, actual.Type, expected.Type)
+		}
+	}
+
+	pineApples := loadFixtures(t)
+	for i, safe := range ToSafePineAppleSlice(pineApples) {
+		expected := reflect.ValueOf(handWrittenToSafePineApple(&pineApples[i]))
+		actual := reflect.ValueOf(safe)
+		for f := 0; f < handWritten.NumField(); f++ {
+			name := handWritten.Field(f).Name
+			if !reflect.DeepEqual(actual.FieldByName(name).Interface(), expected.Field(f).Interface()) {
+				t.Fatalf("pineapple %d field %s: %v expected %v", i, name, actual.FieldByName(name), expected.Field(f))
+			}
+		}
+	}
+}
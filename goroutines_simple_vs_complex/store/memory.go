@@ -0,0 +1,267 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	pineapple "github.com/corentings/goTeaching/goroutines_simple_vs_complex"
+	"github.com/corentings/goTeaching/goroutines_simple_vs_complex/envelope"
+	"github.com/corentings/goTeaching/redact"
+)
+
+// ErrForbidden is returned by RevealSecretCode for a role which may not see the secret codes
+var ErrForbidden = errors.New("store: forbidden")
+
+// MemoryRepository is a Repository in memory, safe for concurrent use
+type MemoryRepository struct {
+	mu         sync.RWMutex
+	pineapples map[uint]pineapple.Pineapple
+	lastID     uint
+	now        func() time.Time
+	keyring    *envelope.Keyring // seals the secret codes when not nil
+	revealers  map[redact.Role]bool
+}
+
+var _ Repository = (*MemoryRepository)(nil)
+
+// NewMemoryRepository returns an empty repository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{pineapples: make(map[uint]pineapple.Pineapple), now: time.Now}
+}
+
+// NewEncryptedMemoryRepository returns an empty repository which stores the secret codes sealed with the keyring.
+// Only the revealers may call RevealSecretCode, they can't be changed afterwards.
+func NewEncryptedMemoryRepository(keyring *envelope.Keyring, revealers ...redact.Role) *MemoryRepository {
+	r := NewMemoryRepository()
+	r.keyring = keyring
+	r.revealers = make(map[redact.Role]bool, len(revealers))
+	for _, role := range revealers {
+		r.revealers[role] = true
+	}
+	return r
+}
+
+// associated binds a sealed secret code to the ID of its pineapple
+func associated(id uint) []byte {
+	return strconv.AppendUint([]byte("pineapple/"), uint64(id), 10)
+}
+
+// storedSecret returns the secret code as it is stored: sealed with the keyring if any, copied otherwise since the caller may reuse its buffer
+func (r *MemoryRepository) storedSecret(p *pineapple.Pineapple) ([]byte, error) {
+	if len(p.SecretCode) == 0 {
+		return nil, nil
+	}
+	if r.keyring == nil {
+		return append([]byte(nil), p.SecretCode...), nil
+	}
+	return r.keyring.Seal(p.SecretCode, associated(p.ID))
+}
+
+// Create validates the pineapple and stores it alive. Its creation time is now unless it is set.
+func (r *MemoryRepository) Create(ctx context.Context, p pineapple.Pineapple) (pineapple.SafePineApple, error) {
+	if err := ctx.Err(); err != nil {
+		return pineapple.SafePineApple{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p.ID == 0 {
+		p.ID = r.lastID + 1
+	} else if _, ok := r.pineapples[p.ID]; ok {
+		return pineapple.SafePineApple{}, fmt.Errorf("%w: %d", ErrExists, p.ID)
+	}
+
+	now := r.now()
+	if p.Created.IsZero() {
+		p.Created = now
+	}
+	p.Updated = now
+	p.IsAlive = true
+	if err := p.Validate(); err != nil {
+		return pineapple.SafePineApple{}, err
+	}
+
+	secret, err := r.storedSecret(&p)
+	if err != nil {
+		return pineapple.SafePineApple{}, err
+	}
+	p.SecretCode = secret
+	r.pineapples[p.ID] = p
+	if p.ID > r.lastID {
+		r.lastID = p.ID
+	}
+	return p.ToSafePineApple(), nil
+}
+
+// Get returns the pineapple with the ID unless it is deleted
+func (r *MemoryRepository) Get(ctx context.Context, id uint) (pineapple.SafePineApple, error) {
+	if err := ctx.Err(); err != nil {
+		return pineapple.SafePineApple{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.pineapples[id]
+	if !ok || !p.IsAlive {
+		return pineapple.SafePineApple{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	return p.ToSafePineApple(), nil
+}
+
+// Update validates and replaces the living pineapple with the same ID, keeping its creation time
+func (r *MemoryRepository) Update(ctx context.Context, p pineapple.Pineapple) (pineapple.SafePineApple, error) {
+	if err := ctx.Err(); err != nil {
+		return pineapple.SafePineApple{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.pineapples[p.ID]
+	if !ok || !previous.IsAlive {
+		return pineapple.SafePineApple{}, fmt.Errorf("%w: %d", ErrNotFound, p.ID)
+	}
+
+	p.Created = previous.Created
+	p.Updated = r.now()
+	p.IsAlive = true
+	if err := p.Validate(); err != nil {
+		return pineapple.SafePineApple{}, err
+	}
+
+	secret, err := r.storedSecret(&p)
+	if err != nil {
+		return pineapple.SafePineApple{}, err
+	}
+	p.SecretCode = secret
+	r.pineapples[p.ID] = p
+	return p.ToSafePineApple(), nil
+}
+
+// Delete marks the living pineapple with the ID as dead
+func (r *MemoryRepository) Delete(ctx context.Context, id uint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.pineapples[id]
+	if !ok || !p.IsAlive {
+		return fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	p.IsAlive = false
+	p.Updated = r.now()
+	r.pineapples[id] = p
+	return nil
+}
+
+// List returns a page of the pineapples matching the query.
+// The matching pineapples are copied under the read lock, then sorted and converted without holding it.
+func (r *MemoryRepository) List(ctx context.Context, q Query) (Page, error) {
+	if err := ctx.Err(); err != nil {
+		return Page{}, err
+	}
+	if err := q.normalize(); err != nil {
+		return Page{}, err
+	}
+	var after *pineapple.Pineapple
+	if q.Cursor != "" {
+		var err error
+		if after, err = q.decodeCursor(); err != nil {
+			return Page{}, err
+		}
+	}
+
+	r.mu.RLock()
+	matches := make([]pineapple.Pineapple, 0, len(r.pineapples))
+	for id := range r.pineapples {
+		p := r.pineapples[id]
+		if q.match(&p) && (after == nil || q.before(after, &p)) {
+			matches = append(matches, p)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool { return q.before(&matches[i], &matches[j]) })
+
+	var page Page
+	if len(matches) > q.Limit {
+		matches = matches[:q.Limit]
+		page.Next = q.encodeCursor(&matches[len(matches)-1])
+	}
+	page.Items = pineapple.ToSafePineAppleSlice(matches)
+	return page, nil
+}
+
+// RevealSecretCode returns the secret code of the living pineapple with the ID, decrypted.
+// It is not part of Repository, and only the revealers given to NewEncryptedMemoryRepository can call it:
+// the fields PineappleRoles shows to a role don't grant it.
+func (r *MemoryRepository) RevealSecretCode(ctx context.Context, role redact.Role, id uint) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !r.revealers[role] {
+		return nil, fmt.Errorf("%w: role %q may not see the secret codes", ErrForbidden, role)
+	}
+
+	r.mu.RLock()
+	p, ok := r.pineapples[id]
+	r.mu.RUnlock()
+	if !ok || !p.IsAlive {
+		return nil, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+
+	if len(p.SecretCode) == 0 || r.keyring == nil {
+		return append([]byte(nil), p.SecretCode...), nil
+	}
+	return r.keyring.Open(p.SecretCode, associated(id))
+}
+
+// RotateSecrets rewraps in parallel the secret codes which are not sealed with the current key of the keyring,
+// so that the previous keys can be retired. It returns the number of rewrapped secrets.
+// The pineapples are not locked while rewrapping, a secret updated in the meantime is already sealed with the current key and is left alone.
+func (r *MemoryRepository) RotateSecrets(ctx context.Context, opts pineapple.MapOptions) (int, error) {
+	if r.keyring == nil {
+		return 0, nil
+	}
+
+	current := r.keyring.Current()
+	var (
+		ids   []uint
+		items []envelope.Item
+	)
+	r.mu.RLock()
+	for id, p := range r.pineapples {
+		if version, err := envelope.Version(p.SecretCode); len(p.SecretCode) > 0 && (err != nil || version != current) {
+			ids = append(ids, id)
+			items = append(items, envelope.Item{Sealed: p.SecretCode, Associated: associated(id)})
+		}
+	}
+	r.mu.RUnlock()
+
+	rewrapped, err := r.keyring.RewrapAll(ctx, items, opts)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for i, id := range ids {
+		p := r.pineapples[id]
+		if rewrapped[i] == nil || !bytes.Equal(p.SecretCode, items[i].Sealed) {
+			continue // failed, or changed while rewrapping
+		}
+		p.SecretCode = rewrapped[i]
+		r.pineapples[id] = p
+		count++
+	}
+	return count, err
+}
@@ -0,0 +1,408 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	pineapple "github.com/corentings/goTeaching/goroutines_simple_vs_complex"
+	"github.com/corentings/goTeaching/goroutines_simple_vs_complex/envelope"
+	"github.com/corentings/goTeaching/redact"
+)
+
+var epoch = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestRepository returns a repository whose clock ticks one minute on every call
+func newTestRepository() *MemoryRepository {
+	r := NewMemoryRepository()
+	var mu sync.Mutex
+	now := epoch
+	r.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(time.Minute)
+		return now
+	}
+	return r
+}
+
+func newPineapple(name string, age int) pineapple.Pineapple {
+	return pineapple.Pineapple{Paro: name, Turkey: "turkey", Banana: "banana", Age: age, SecretCode: []byte("secret")}
+}
+
+// seed creates n pineapples named pine-<i>, aged i%10, created i days after a year before the epoch
+func seed(t testing.TB, r *MemoryRepository, n int) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		p := newPineapple(fmt.Sprintf("pine-%03d", i), i%10)
+		p.Created = epoch.AddDate(-1, 0, i) // before the clock of the repository
+		if _, err := r.Create(context.Background(), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMemoryRepositoryCRUD(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepository()
+
+	created, err := r.Create(ctx, newPineapple("paro", 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := pineapple.SafePineApple{Paro: "paro", Turkey: "turkey", Banana: "banana", Age: 3, IsAlive: true, ID: 1}
+	if created != expected {
+		t.Errorf("created %v expected %v", created, expected)
+	}
+	if got, err := r.Get(ctx, 1); err != nil || got != expected {
+		t.Errorf("get %v %v expected %v", got, err, expected)
+	}
+
+	if _, err := r.Create(ctx, pineapple.Pineapple{ID: 1, Paro: "p", Turkey: "t", Banana: "b"}); !errors.Is(err, ErrExists) {
+		t.Errorf("expected %v got %v", ErrExists, err)
+	}
+	if second, _ := r.Create(ctx, newPineapple("second", 1)); second.ID != 2 {
+		t.Errorf("second ID %d expected 2", second.ID)
+	}
+	if _, err := r.Create(ctx, newPineapple("", -1)); err == nil {
+		t.Error("expected a validation error")
+	}
+
+	update := newPineapple("updated", 4)
+	update.ID = 1
+	if updated, err := r.Update(ctx, update); err != nil || updated.Paro != "updated" || updated.Age != 4 {
+		t.Errorf("updated %v %v", updated, err)
+	}
+	if stored := r.pineapples[1]; !stored.Created.Equal(epoch.Add(time.Minute)) || !stored.Updated.After(stored.Created) {
+		t.Errorf("update changed the creation time %v or didn't touch %v", stored.Created, stored.Updated)
+	}
+	update.ID = 42
+	if _, err := r.Update(ctx, update); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected %v got %v", ErrNotFound, err)
+	}
+
+	if err := r.Delete(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Get(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected %v got %v", ErrNotFound, err)
+	}
+	if err := r.Delete(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted twice: expected %v got %v", ErrNotFound, err)
+	}
+	update.ID = 1
+	if _, err := r.Update(ctx, update); !errors.Is(err, ErrNotFound) {
+		t.Errorf("updated a deleted pineapple: expected %v got %v", ErrNotFound, err)
+	}
+	if stored, ok := r.pineapples[1]; !ok || stored.IsAlive {
+		t.Errorf("soft delete should keep the pineapple dead: %v %v", stored, ok)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := r.Get(canceled, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v got %v", context.Canceled, err)
+	}
+}
+
+func TestMemoryRepositoryKeepsItsOwnSecret(t *testing.T) {
+	r := newTestRepository()
+	p := newPineapple("paro", 1)
+	if _, err := r.Create(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+	copy(p.SecretCode, "XXXXXX")
+	if secret := string(r.pineapples[1].SecretCode); secret != "secret" {
+		t.Errorf("stored secret %q changed with the caller's buffer", secret)
+	}
+}
+
+func ids(items []pineapple.SafePineApple) []uint {
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func intPtr(i int) *int { return &i }
+
+func TestMemoryRepositoryList(t *testing.T) {
+	r := newTestRepository()
+	seed(t, r, 30)
+	for _, id := range []uint{3, 13} {
+		if err := r.Delete(context.Background(), id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		query    Query
+		expected []uint
+	}{
+		{"name", Query{Filter: Filter{Name: "PINE-01"}}, []uint{10, 11, 12, 14, 15, 16, 17, 18, 19}},
+		{"deleted", Query{Filter: Filter{Name: "pine-01", IncludeDeleted: true}, Limit: 3}, []uint{10, 11, 12}},
+		{"age range", Query{Filter: Filter{MinAge: intPtr(3), MaxAge: intPtr(4)}}, []uint{4, 14, 23, 24}},
+		{"age desc", Query{Filter: Filter{MinAge: intPtr(8)}, Sort: SortByAge, Desc: true}, []uint{29, 19, 9, 28, 18, 8}},
+		{"created range", Query{Filter: Filter{CreatedAfter: epoch.AddDate(-1, 0, 5), CreatedBefore: epoch.AddDate(-1, 0, 8)}}, []uint{5, 6, 7}},
+		{"name desc", Query{Sort: SortByName, Desc: true, Limit: 3}, []uint{30, 29, 28}},
+		{"created", Query{Sort: SortByCreated, Limit: 4}, []uint{1, 2, 4, 5}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page, err := r.List(context.Background(), test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := ids(page.Items); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("actual %v expected %v", actual, test.expected)
+			}
+		})
+	}
+
+	for _, query := range []Query{{Sort: "color"}, {Limit: -1}} {
+		if _, err := r.List(context.Background(), query); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%+v: expected %v got %v", query, ErrInvalidQuery, err)
+		}
+	}
+}
+
+func TestMemoryRepositoryPagination(t *testing.T) {
+	r := newTestRepository()
+	seed(t, r, 95)
+
+	for _, query := range []Query{{Limit: 10}, {Sort: SortByAge, Limit: 7}, {Sort: SortByCreated, Desc: true, Limit: 20}, {Sort: SortByName, Limit: 95}} {
+		t.Run(fmt.Sprintf("%s/%v/%d", query.Sort, query.Desc, query.Limit), func(t *testing.T) {
+			all := query
+			all.Limit = MaxLimit
+			full, err := r.List(context.Background(), all)
+			if err != nil || full.Next != "" || len(full.Items) != 95 {
+				t.Fatalf("full list: %d items next %q error %v", len(full.Items), full.Next, err)
+			}
+
+			var walked []pineapple.SafePineApple
+			for pages := 0; ; pages++ {
+				if pages > 95 {
+					t.Fatal("the pagination doesn't end")
+				}
+				page, err := r.List(context.Background(), query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				walked = append(walked, page.Items...)
+				if page.Next == "" {
+					break
+				}
+				query.Cursor = page.Next
+			}
+			if !reflect.DeepEqual(ids(walked), ids(full.Items)) {
+				t.Errorf("pages %v expected %v", ids(walked), ids(full.Items))
+			}
+		})
+	}
+
+	// The cursor is a position, a pineapple created between two pages is not skipped nor repeated
+	page, _ := r.List(context.Background(), Query{Limit: 90})
+	if _, err := r.Create(context.Background(), newPineapple("late", 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Delete(context.Background(), 95); err != nil {
+		t.Fatal(err)
+	}
+	next, _ := r.List(context.Background(), Query{Limit: 90, Cursor: page.Next})
+	if actual, expected := ids(next.Items), []uint{91, 92, 93, 94, 96}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("next page %v expected %v", actual, expected)
+	}
+
+	for _, cursor := range []string{"not base64!", "bm90IGpzb24", page.Next} {
+		if _, err := r.List(context.Background(), Query{Sort: SortByAge, Cursor: cursor}); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("cursor %q: expected %v got %v", cursor, ErrInvalidCursor, err)
+		}
+	}
+}
+
+func TestMemoryRepositoryConcurrentUse(t *testing.T) {
+	r := newTestRepository()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				created, err := r.Create(ctx, newPineapple(fmt.Sprintf("w%d-%d", w, i), i))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				p := newPineapple("renamed", i+1)
+				p.ID = created.ID
+				if _, err := r.Update(ctx, p); err != nil {
+					t.Error(err)
+				}
+				if i%2 == 0 {
+					if err := r.Delete(ctx, created.ID); err != nil {
+						t.Error(err)
+					}
+				}
+				if _, err := r.List(ctx, Query{Sort: SortByAge, Limit: 5}); err != nil {
+					t.Error(err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	page, err := r.List(ctx, Query{Limit: MaxLimit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 8*25 || len(r.pineapples) != 8*50 {
+		t.Errorf("%d living pineapples out of %d, expected 200 out of 400", len(page.Items), len(r.pineapples))
+	}
+	seen := make(map[uint]bool)
+	for _, item := range page.Items {
+		if seen[item.ID] || item.Paro != "renamed" {
+			t.Errorf("unexpected item %v", item)
+		}
+		seen[item.ID] = true
+	}
+}
+
+func newEncryptedTestRepository(t *testing.T) (*MemoryRepository, *envelope.Keyring) {
+	t.Helper()
+	keyring, err := envelope.NewKeyring(1, envelope.GenerateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newTestRepository()
+	r.keyring = keyring
+	r.revealers = map[redact.Role]bool{pineapple.RoleAdmin: true}
+	return r, keyring
+}
+
+func TestEncryptedMemoryRepository(t *testing.T) {
+	ctx := context.Background()
+	r, _ := newEncryptedTestRepository(t)
+
+	created, err := r.Create(ctx, newPineapple("paro", 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored := r.pineapples[created.ID].SecretCode; len(stored) == 0 || bytes.Contains(stored, []byte("secret")) {
+		t.Errorf("secret code stored in plain text: %q", stored)
+	}
+
+	if secret, err := r.RevealSecretCode(ctx, pineapple.RoleAdmin, created.ID); err != nil || string(secret) != "secret" {
+		t.Errorf("admin revealed %q %v", secret, err)
+	}
+	for _, role := range []redact.Role{pineapple.RoleAuditor, pineapple.RolePublic, "guest"} {
+		if _, err := r.RevealSecretCode(ctx, role, created.ID); !errors.Is(err, ErrForbidden) {
+			t.Errorf("%s: expected %v got %v", role, ErrForbidden, err)
+		}
+	}
+	if _, err := r.RevealSecretCode(ctx, pineapple.RoleAdmin, 42); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected %v got %v", ErrNotFound, err)
+	}
+
+	// The revealers are those of the repository, whatever the roles may see
+	locked := NewEncryptedMemoryRepository(r.keyring)
+	if _, err := locked.Create(ctx, newPineapple("paro", 3)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := locked.RevealSecretCode(ctx, pineapple.RoleAdmin, 1); !errors.Is(err, ErrForbidden) {
+		t.Errorf("no revealer: expected %v got %v", ErrForbidden, err)
+	}
+
+	update := newPineapple("paro", 4)
+	update.ID, update.SecretCode = created.ID, []byte("rotated secret")
+	if _, err := r.Update(ctx, update); err != nil {
+		t.Fatal(err)
+	}
+	if secret, _ := r.RevealSecretCode(ctx, pineapple.RoleAdmin, created.ID); string(secret) != "rotated secret" {
+		t.Errorf("revealed %q after the update", secret)
+	}
+
+	empty := newPineapple("empty", 1)
+	empty.SecretCode = nil
+	if created, _ := r.Create(ctx, empty); r.pineapples[created.ID].SecretCode != nil {
+		t.Error("an empty secret code should stay empty")
+	}
+}
+
+func TestEncryptedMemoryRepositoryTampering(t *testing.T) {
+	ctx := context.Background()
+	r, _ := newEncryptedTestRepository(t)
+	seed(t, r, 2)
+
+	// A flipped byte, or a secret moved to another pineapple, doesn't decrypt
+	first := r.pineapples[1]
+	first.SecretCode[len(first.SecretCode)-1] ^= 1
+	if _, err := r.RevealSecretCode(ctx, pineapple.RoleAdmin, 1); !errors.Is(err, envelope.ErrDecrypt) {
+		t.Errorf("tampered: expected %v got %v", envelope.ErrDecrypt, err)
+	}
+
+	second := r.pineapples[2]
+	second.SecretCode = r.pineapples[1].SecretCode
+	first.SecretCode[len(first.SecretCode)-1] ^= 1
+	r.pineapples[2] = second
+	if _, err := r.RevealSecretCode(ctx, pineapple.RoleAdmin, 2); !errors.Is(err, envelope.ErrDecrypt) {
+		t.Errorf("moved: expected %v got %v", envelope.ErrDecrypt, err)
+	}
+	if secret, err := r.RevealSecretCode(ctx, pineapple.RoleAdmin, 1); err != nil || string(secret) != "secret" {
+		t.Errorf("restored: %q %v", secret, err)
+	}
+}
+
+func TestRotateSecrets(t *testing.T) {
+	ctx := context.Background()
+	r, keyring := newEncryptedTestRepository(t)
+	seed(t, r, 200)
+
+	if err := keyring.Rotate(2, envelope.GenerateKey()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Updates during the rotation are sealed with the new key and must not be overwritten
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for id := uint(1); id <= 200; id += 10 {
+			p := newPineapple("updated", 1)
+			p.ID, p.SecretCode = id, []byte("updated secret")
+			if _, err := r.Update(ctx, p); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	rotated, err := r.RotateSecrets(ctx, pineapple.MapOptions{Workers: 4, ChunkSize: 16})
+	wg.Wait()
+	if err != nil || rotated < 180 || rotated > 200 {
+		t.Fatalf("rotated %d secrets, error %v", rotated, err)
+	}
+
+	if err := keyring.Retire(1); err != nil {
+		t.Fatal(err)
+	}
+	for id := uint(1); id <= 200; id++ {
+		expected := "secret"
+		if id%10 == 1 {
+			expected = "updated secret"
+		}
+		if secret, err := r.RevealSecretCode(ctx, pineapple.RoleAdmin, id); err != nil || string(secret) != expected {
+			t.Fatalf("pineapple %d: revealed %q %v expected %q", id, secret, err, expected)
+		}
+	}
+
+	if rotated, err := r.RotateSecrets(ctx, pineapple.MapOptions{}); rotated != 0 || err != nil {
+		t.Errorf("second rotation rewrapped %d secrets, error %v", rotated, err)
+	}
+}
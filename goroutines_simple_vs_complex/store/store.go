@@ -0,0 +1,195 @@
+// Package store keeps the pineapples behind a repository which only hands out SafePineApple values
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	pineapple "github.com/corentings/goTeaching/goroutines_simple_vs_complex"
+)
+
+var (
+	// ErrNotFound is returned for an unknown or deleted pineapple
+	ErrNotFound = errors.New("store: pineapple not found")
+	// ErrExists is returned when creating a pineapple with the ID of another one
+	ErrExists = errors.New("store: pineapple already exists")
+	// ErrInvalidCursor is returned for a cursor which doesn't come from a page of the same query
+	ErrInvalidCursor = errors.New("store: invalid cursor")
+	// ErrInvalidQuery is returned for an unknown sort field or a negative limit
+	ErrInvalidQuery = errors.New("store: invalid query")
+)
+
+// Repository stores pineapples. The sensitive fields go in but never come out: every read returns SafePineApple values.
+type Repository interface {
+	// Create stores a new pineapple, alive, and returns it with its ID. An ID is assigned when it is 0.
+	Create(ctx context.Context, p pineapple.Pineapple) (pineapple.SafePineApple, error)
+	// Get returns the pineapple with the ID unless it is deleted
+	Get(ctx context.Context, id uint) (pineapple.SafePineApple, error)
+	// Update replaces the pineapple with the same ID, keeping its creation time
+	Update(ctx context.Context, p pineapple.Pineapple) (pineapple.SafePineApple, error)
+	// Delete marks the pineapple as dead, it is then hidden from Get and from the lists unless asked for
+	Delete(ctx context.Context, id uint) error
+	// List returns a page of the pineapples matching the query
+	List(ctx context.Context, q Query) (Page, error)
+}
+
+// SortField is the field the lists are sorted by, the ID breaks the ties. SortByName sorts by Paro.
+type SortField string
+
+// Sort fields
+const (
+	SortByID      SortField = "id"
+	SortByName    SortField = "name"
+	SortByAge     SortField = "age"
+	SortByCreated SortField = "created"
+)
+
+// DefaultLimit is the size of a page when the query doesn't set one, MaxLimit is the largest one
+const (
+	DefaultLimit = 50
+	MaxLimit     = 1000
+)
+
+// Filter selects pineapples, the zero Filter selects every living one
+type Filter struct {
+	Name           string    // case insensitive substring of Paro, Turkey or Banana
+	MinAge, MaxAge *int      // inclusive bounds when set
+	CreatedAfter   time.Time // inclusive bound when not zero
+	CreatedBefore  time.Time // exclusive bound when not zero
+	IncludeDeleted bool
+}
+
+// Query is a filter with the order and the page to return
+type Query struct {
+	Filter
+	Sort   SortField // SortByID by default
+	Desc   bool
+	Limit  int    // DefaultLimit when 0, at most MaxLimit
+	Cursor string // Page.Next of the previous page, empty for the first one
+}
+
+// Page is a page of a list. Next is empty on the last page.
+type Page struct {
+	Items []pineapple.SafePineApple
+	Next  string
+}
+
+func (f *Filter) match(p *pineapple.Pineapple) bool {
+	if !p.IsAlive && !f.IncludeDeleted {
+		return false
+	}
+	if f.Name != "" {
+		name := strings.ToLower(f.Name)
+		if !strings.Contains(strings.ToLower(p.Paro), name) &&
+			!strings.Contains(strings.ToLower(p.Turkey), name) &&
+			!strings.Contains(strings.ToLower(p.Banana), name) {
+			return false
+		}
+	}
+	if (f.MinAge != nil && p.Age < *f.MinAge) || (f.MaxAge != nil && p.Age > *f.MaxAge) {
+		return false
+	}
+	if (!f.CreatedAfter.IsZero() && p.Created.Before(f.CreatedAfter)) || (!f.CreatedBefore.IsZero() && !p.Created.Before(f.CreatedBefore)) {
+		return false
+	}
+	return true
+}
+
+// normalize checks the query and sets its defaults
+func (q *Query) normalize() error {
+	switch q.Sort {
+	case "":
+		q.Sort = SortByID
+	case SortByID, SortByName, SortByAge, SortByCreated:
+	default:
+		return fmt.Errorf("%w: unknown sort field %q", ErrInvalidQuery, q.Sort)
+	}
+
+	switch {
+	case q.Limit < 0:
+		return fmt.Errorf("%w: negative limit %d", ErrInvalidQuery, q.Limit)
+	case q.Limit == 0:
+		q.Limit = DefaultLimit
+	case q.Limit > MaxLimit:
+		q.Limit = MaxLimit
+	}
+	return nil
+}
+
+// compare orders two pineapples by the sort field then by ID, in ascending order
+func (q *Query) compare(a, b *pineapple.Pineapple) int {
+	c := 0
+	switch q.Sort {
+	case SortByName:
+		c = strings.Compare(a.Paro, b.Paro)
+	case SortByAge:
+		c = compareInts(a.Age, b.Age)
+	case SortByCreated:
+		c = a.Created.Compare(b.Created)
+	}
+	if c == 0 {
+		c = compareInts(int64(a.ID), int64(b.ID))
+	}
+	return c
+}
+
+// before tells whether a comes before b in the order of the query
+func (q *Query) before(a, b *pineapple.Pineapple) bool {
+	if q.Desc {
+		return q.compare(a, b) > 0
+	}
+	return q.compare(a, b) < 0
+}
+
+func compareInts[T int | int64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// cursor is the position after the last item of a page.
+// It holds the sort key of the item rather than an offset, so that the next page doesn't skip or repeat items when the store changes.
+type cursor struct {
+	Sort    SortField `json:"s"`
+	Desc    bool      `json:"d,omitempty"`
+	ID      uint      `json:"i"`
+	Name    string    `json:"n,omitempty"`
+	Age     int       `json:"a,omitempty"`
+	Created time.Time `json:"c,omitempty"`
+}
+
+func (q *Query) encodeCursor(last *pineapple.Pineapple) string {
+	c := cursor{Sort: q.Sort, Desc: q.Desc, ID: last.ID}
+	switch q.Sort {
+	case SortByName:
+		c.Name = last.Paro
+	case SortByAge:
+		c.Age = last.Age
+	case SortByCreated:
+		c.Created = last.Created
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the last item of the previous page, with only its sort key set
+func (q *Query) decodeCursor() (*pineapple.Pineapple, error) {
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != q.Sort || c.Desc != q.Desc {
+		return nil, ErrInvalidCursor
+	}
+	return &pineapple.Pineapple{ID: c.ID, Paro: c.Name, Age: c.Age, Created: c.Created}, nil
+}
@@ -2,37 +2,19 @@ package goroutines_simple_vs_complex
 
 import "time"
 
-// Pineapple is a struct that represents a database object with sensitive data that should be hidden
+//go:generate go run ../redact/cmd/safegen -type Pineapple -safe SafePineApple -output pineapple_safe.go -keep-tags json -order Paro,Turkey,Banana,IsAlive,Age
+
+// Pineapple is a struct that represents a database object with sensitive data that should be hidden.
+// The safe tags tell the redact package which fields must not leave the database.
 type Pineapple struct {
-	Paro       string `faker:"name"`
-	Turkey     string `faker:"name"`
-	Banana     string `faker:"name"`
-	Age        int    `faker:"number"`
-	Size       int    `faker:"number"`
+	Paro       string
+	Turkey     string
+	Banana     string
+	Age        int
+	Size       int `safe:"omit"`
 	IsAlive    bool
 	ID         uint
-	SecretCode []byte
-	Created    time.Time
-	Updated    time.Time
-}
-
-func (p *Pineapple) ToSafePineApple() SafePineApple {
-	return SafePineApple{
-		Paro:    p.Paro,
-		Turkey:  p.Turkey,
-		Banana:  p.Banana,
-		IsAlive: p.IsAlive,
-		Age:     p.Age,
-		ID:      p.ID,
-	}
-}
-
-// SafePineApple is a struct that represents a Pineapple object without sensitive data
-type SafePineApple struct {
-	Paro    string
-	Turkey  string
-	Banana  string
-	IsAlive bool
-	Age     int
-	ID      uint
+	SecretCode []byte    `safe:"omit"`
+	Created    time.Time `safe:"omit"`
+	Updated    time.Time `safe:"omit"`
 }
@@ -0,0 +1,189 @@
+package goroutines_simple_vs_complex
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of the validation rules
+const (
+	RuleNotEmpty      = "not_empty"
+	RuleNonNegative   = "non_negative"
+	RuleAfterCreation = "after_created"
+	RuleUniqueID      = "unique"
+)
+
+// Rule is a check on a single field of a pineapple
+type Rule struct {
+	Field string
+	Name  string
+	Valid func(p *Pineapple) bool
+}
+
+func notEmpty(field string, get func(p *Pineapple) string) Rule {
+	return Rule{Field: field, Name: RuleNotEmpty, Valid: func(p *Pineapple) bool { return get(p) != "" }}
+}
+
+func nonNegative(field string, get func(p *Pineapple) int) Rule {
+	return Rule{Field: field, Name: RuleNonNegative, Valid: func(p *Pineapple) bool { return get(p) >= 0 }}
+}
+
+// PineappleRules are the rules every pineapple must follow, in the order they are reported.
+// The IDs must also be unique in a batch, which RuleUniqueID reports on every duplicate but the first.
+var PineappleRules = []Rule{
+	notEmpty("Paro", func(p *Pineapple) string { return p.Paro }),
+	notEmpty("Turkey", func(p *Pineapple) string { return p.Turkey }),
+	notEmpty("Banana", func(p *Pineapple) string { return p.Banana }),
+	nonNegative("Age", func(p *Pineapple) int { return p.Age }),
+	nonNegative("Size", func(p *Pineapple) int { return p.Size }),
+	{Field: "Updated", Name: RuleAfterCreation, Valid: func(p *Pineapple) bool { return !p.Updated.Before(p.Created) }},
+}
+
+// Violation is a rule broken by the pineapple at Index
+type Violation struct {
+	Index int
+	Field string
+	Rule  string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("item %d: %s: %s", v.Index, v.Field, v.Rule)
+}
+
+// ValidationReport lists the violations of a batch, sorted by index then in the order of the rules
+type ValidationReport []Violation
+
+// Error lists the violations, one per line
+func (r ValidationReport) Error() string {
+	lines := make([]string, len(r))
+	for i, v := range r {
+		lines[i] = v.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Err returns the report as an error, or nil when there is no violation
+func (r ValidationReport) Err() error {
+	if len(r) == 0 {
+		return nil
+	}
+	return r
+}
+
+// Invalid tells whether the pineapple at index broke any rule
+func (r ValidationReport) Invalid(index int) bool {
+	i := sort.Search(len(r), func(i int) bool { return r[i].Index >= index })
+	return i < len(r) && r[i].Index == index
+}
+
+// Validate checks the pineapple against PineappleRules, the violations are reported at index 0
+func (p *Pineapple) Validate() error {
+	return validateOne(0, p, nil).Err()
+}
+
+func validateOne(index int, p *Pineapple, report ValidationReport) ValidationReport {
+	for _, rule := range PineappleRules {
+		if !rule.Valid(p) {
+			report = append(report, Violation{Index: index, Field: rule.Field, Rule: rule.Name})
+		}
+	}
+	return report
+}
+
+// ValidatePineApples checks every pineapple against PineappleRules and the IDs for duplicates.
+// The error is not nil only when ctx is done before the end, the report then covers part of the batch.
+func ValidatePineApples(ctx context.Context, pineapples []Pineapple, opts MapOptions) (ValidationReport, error) {
+	return validatePineApples(ctx, pineapples, opts, nil)
+}
+
+// ConvertValidPineApplesToSafety validates and converts the pineapples in the same pass.
+// The result has the same length as the input, the invalid pineapples are left to the zero SafePineApple.
+func ConvertValidPineApplesToSafety(ctx context.Context, pineapples []Pineapple, opts MapOptions) ([]SafePineApple, ValidationReport, error) {
+	safePineApples := make([]SafePineApple, len(pineapples))
+	report, err := validatePineApples(ctx, pineapples, opts, func(i int) {
+		safePineApples[i] = pineapples[i].ToSafePineApple()
+	})
+
+	// Duplicates are only known once every chunk is merged
+	for _, v := range report {
+		safePineApples[v.Index] = SafePineApple{}
+	}
+	return safePineApples, report, err
+}
+
+// span is a chunk of a batch
+type span struct{ start, end int }
+
+// spans splits n items into chunks the way ParallelMap does
+func spans(n int, opts MapOptions) []span {
+	size := opts.ChunkSize
+	if size <= 0 {
+		workers := opts.Workers
+		if workers <= 0 {
+			workers = runtime.NumCPU()
+		}
+		size = (n + workers - 1) / workers
+	}
+	if size < 1 {
+		size = 1
+	}
+
+	chunks := make([]span, 0, (n+size-1)/size)
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		chunks = append(chunks, span{start, end})
+	}
+	return chunks
+}
+
+// validatePineApples validates the chunks with ParallelMap. Each chunk builds its own report and IDs,
+// merged into the batch ones as soon as it is done. valid is called on the pineapples which follow the rules.
+func validatePineApples(ctx context.Context, pineapples []Pineapple, opts MapOptions, valid func(i int)) (ValidationReport, error) {
+	var (
+		mu     sync.Mutex
+		report ValidationReport
+		ids    = make(map[uint][]int, len(pineapples))
+	)
+
+	_, err := ParallelMap(ctx, spans(len(pineapples), opts), func(s span) (struct{}, error) {
+		var chunkReport ValidationReport
+		chunkIDs := make(map[uint][]int, s.end-s.start)
+		for i := s.start; i < s.end; i++ {
+			n := len(chunkReport)
+			chunkReport = validateOne(i, &pineapples[i], chunkReport)
+			if len(chunkReport) == n && valid != nil {
+				valid(i)
+			}
+			chunkIDs[pineapples[i].ID] = append(chunkIDs[pineapples[i].ID], i)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		report = append(report, chunkReport...)
+		for id, indexes := range chunkIDs {
+			ids[id] = append(ids[id], indexes...)
+		}
+		return struct{}{}, nil
+	}, MapOptions{Workers: opts.Workers, ChunkSize: 1})
+
+	for _, indexes := range ids {
+		if len(indexes) < 2 {
+			continue
+		}
+		sort.Ints(indexes)
+		for _, i := range indexes[1:] {
+			report = append(report, Violation{Index: i, Field: "ID", Rule: RuleUniqueID})
+		}
+	}
+
+	// The chunks are merged in any order but each index belongs to a single chunk, and the duplicates come last
+	sort.SliceStable(report, func(i, j int) bool { return report[i].Index < report[j].Index })
+	return report, err
+}
@@ -0,0 +1,137 @@
+package goroutines_simple_vs_complex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPineappleValidate(t *testing.T) {
+	pine := testPineapple(1)
+	if err := pine.Validate(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		change   func(p *Pineapple)
+		expected ValidationReport
+	}{
+		{"empty name", func(p *Pineapple) { p.Turkey = "" }, ValidationReport{{0, "Turkey", RuleNotEmpty}}},
+		{"negative age", func(p *Pineapple) { p.Age = -1 }, ValidationReport{{0, "Age", RuleNonNegative}}},
+		{"negative size", func(p *Pineapple) { p.Size = -3 }, ValidationReport{{0, "Size", RuleNonNegative}}},
+		{"updated before created", func(p *Pineapple) { p.Updated = p.Created.Add(-time.Second) }, ValidationReport{{0, "Updated", RuleAfterCreation}}},
+		{"updated when created", func(p *Pineapple) { p.Updated = p.Created }, nil},
+		{"several rules", func(p *Pineapple) { p.Paro, p.Banana, p.Size = "", "", -1 },
+			ValidationReport{{0, "Paro", RuleNotEmpty}, {0, "Banana", RuleNotEmpty}, {0, "Size", RuleNonNegative}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pine := testPineapple(1)
+			test.change(&pine)
+
+			err := pine.Validate()
+			var report ValidationReport
+			if err != nil && !errors.As(err, &report) {
+				t.Fatalf("error %v is not a ValidationReport", err)
+			}
+			if !reflect.DeepEqual(report, test.expected) {
+				t.Errorf("actual %v expected %v", report, test.expected)
+			}
+		})
+	}
+}
+
+// invalidBatch returns a batch where every 10th pineapple has a negative age and every 7th reuses the ID 0
+func invalidBatch(n int) ([]Pineapple, ValidationReport) {
+	pineApples := make([]Pineapple, n)
+	var expected ValidationReport
+	for i := range pineApples {
+		pineApples[i] = testPineapple(i)
+		if i%10 == 5 {
+			pineApples[i].Age = -i
+			expected = append(expected, Violation{i, "Age", RuleNonNegative})
+		}
+		if i%7 == 0 {
+			pineApples[i].ID = 0
+			if i > 0 {
+				expected = append(expected, Violation{i, "ID", RuleUniqueID})
+			}
+		}
+	}
+	return pineApples, expected
+}
+
+func TestValidatePineApples(t *testing.T) {
+	pineApples, expected := invalidBatch(1000)
+
+	for _, opts := range []MapOptions{{}, {Workers: 1}, {Workers: 4, ChunkSize: 3}, {Workers: 8, ChunkSize: 64}, {Workers: 3, ChunkSize: 5000}} {
+		t.Run(fmt.Sprintf("%+v", opts), func(t *testing.T) {
+			report, err := ValidatePineApples(context.Background(), pineApples, opts)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(report, expected) {
+				t.Errorf("actual %v\nexpected %v", report, expected)
+			}
+		})
+	}
+
+	if report, err := ValidatePineApples(context.Background(), nil, MapOptions{}); report != nil || err != nil {
+		t.Errorf("empty batch: %v %v", report, err)
+	}
+}
+
+func TestConvertValidPineApplesToSafety(t *testing.T) {
+	pineApples, expected := invalidBatch(500)
+
+	safePineApples, report, err := ConvertValidPineApplesToSafety(context.Background(), pineApples, MapOptions{Workers: 4, ChunkSize: 16})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Fatalf("actual %v\nexpected %v", report, expected)
+	}
+	if len(safePineApples) != len(pineApples) {
+		t.Fatalf("%d safe pineapples expected %d", len(safePineApples), len(pineApples))
+	}
+
+	for i, safe := range safePineApples {
+		expected := pineApples[i].ToSafePineApple()
+		if report.Invalid(i) {
+			expected = SafePineApple{}
+		}
+		if safe != expected {
+			t.Errorf("item %d: actual %v expected %v", i, safe, expected)
+		}
+	}
+}
+
+func TestValidatePineApplesCanceled(t *testing.T) {
+	pineApples, _ := invalidBatch(100)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ValidatePineApples(ctx, pineApples, MapOptions{Workers: 2, ChunkSize: 10}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v got %v", context.Canceled, err)
+	}
+}
+
+func TestValidationReport(t *testing.T) {
+	report := ValidationReport{{1, "Age", RuleNonNegative}, {1, "ID", RuleUniqueID}, {4, "Paro", RuleNotEmpty}}
+	for i, invalid := range []bool{false, true, false, false, true, false} {
+		if report.Invalid(i) != invalid {
+			t.Errorf("Invalid(%d) = %v", i, !invalid)
+		}
+	}
+	if expected := "item 1: Age: non_negative\nitem 1: ID: unique\nitem 4: Paro: not_empty"; report.Error() != expected {
+		t.Errorf("actual %q expected %q", report.Error(), expected)
+	}
+	if ValidationReport(nil).Err() != nil {
+		t.Error("an empty report is not an error")
+	}
+}
@@ -0,0 +1,170 @@
+package goroutines_sum_square
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ErrAggregationStrategy is returned when an aggregation other than ChannelAggregation is used with another strategy than PerCPU
+var ErrAggregationStrategy = errors.New("goroutines_sum_square: aggregation only applies to the PerCPU strategy")
+
+// Aggregation selects how the PerCPU workers combine their partial sums.
+//
+// Every aggregation except ChannelAggregation writes to shared memory for each item,
+// they are meant to measure the cost of contention and false sharing, not to be fast.
+type Aggregation int
+
+const (
+	// ChannelAggregation sums locally and sends one partial sum per worker over a channel, this is the default
+	ChannelAggregation Aggregation = iota
+	// SharedSliceAggregation adds each square to the worker slot of a shared []int.
+	// The slots share cache lines, so the workers keep invalidating each other's caches (false sharing).
+	SharedSliceAggregation
+	// PaddedSliceAggregation is SharedSliceAggregation with each slot padded to its own cache line
+	PaddedSliceAggregation
+	// AtomicAggregation adds each square to a single counter with sync/atomic
+	AtomicAggregation
+	// MutexAggregation adds each square to a single counter guarded by a sync.Mutex
+	MutexAggregation
+)
+
+func (a Aggregation) String() string {
+	switch a {
+	case ChannelAggregation:
+		return "channel"
+	case SharedSliceAggregation:
+		return "shared-slice"
+	case PaddedSliceAggregation:
+		return "padded-slice"
+	case AtomicAggregation:
+		return "atomic"
+	case MutexAggregation:
+		return "mutex"
+	default:
+		return fmt.Sprintf("Aggregation(%d)", int(a))
+	}
+}
+
+// WithAggregation selects how the PerCPU strategy combines the partial sums, ChannelAggregation by default.
+// The other strategies, and PerCPU with a pool, only support ChannelAggregation and return ErrAggregationStrategy otherwise.
+func WithAggregation(aggregation Aggregation) Option {
+	return func(c *config) {
+		c.aggregation = aggregation
+	}
+}
+
+// cacheLineSize is the size of a cache line on most amd64 and arm64 CPUs
+const cacheLineSize = 64
+
+// paddedInt occupies a whole cache line so that two paddedInt never share one
+type paddedInt struct {
+	value int
+	_     [cacheLineSize - unsafe.Sizeof(int(0))]byte
+}
+
+// aggregatedSumSquareContext splits the items into one block per worker like parallelSumSquareContext,
+// and combines the squares with the aggregation of cfg.
+func aggregatedSumSquareContext(ctx context.Context, items []int, cfg config) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(items) <= cfg.threshold {
+		return simpleSumSquare(items), nil
+	}
+
+	var (
+		shared     = make([]int, cfg.workers)
+		padded     = make([]paddedInt, cfg.workers)
+		atomicSum  atomic.Int64
+		mutex      sync.Mutex
+		mutexSum   int
+		accumulate func(worker, start, end int)
+	)
+
+	switch cfg.aggregation {
+	case SharedSliceAggregation:
+		accumulate = func(worker, start, end int) {
+			for i := start; i < end; i++ {
+				shared[worker] += items[i] * items[i] // neighbour slots live on the same cache line
+			}
+		}
+	case PaddedSliceAggregation:
+		accumulate = func(worker, start, end int) {
+			for i := start; i < end; i++ {
+				padded[worker].value += items[i] * items[i] // each slot has its own cache line
+			}
+		}
+	case AtomicAggregation:
+		accumulate = func(_, start, end int) {
+			for i := start; i < end; i++ {
+				atomicSum.Add(int64(items[i] * items[i]))
+			}
+		}
+	case MutexAggregation:
+		accumulate = func(_, start, end int) {
+			for i := start; i < end; i++ {
+				mutex.Lock()
+				mutexSum += items[i] * items[i]
+				mutex.Unlock()
+			}
+		}
+	default:
+		return 0, fmt.Errorf("goroutines_sum_square: unknown aggregation %v", cfg.aggregation)
+	}
+
+	chunkSize := (len(items) + cfg.workers - 1) / cfg.workers
+	wg := sync.WaitGroup{}
+
+	for w := 0; w < cfg.workers; w++ {
+		start := w * chunkSize
+		end := start + chunkSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		wg.Add(1)
+		go func(worker, start, end int) {
+			defer wg.Done()
+			for chunkStart := start; chunkStart < end; chunkStart += cfg.chunkSize {
+				if ctx.Err() != nil {
+					return
+				}
+
+				chunkEnd := chunkStart + cfg.chunkSize
+				if chunkEnd > end {
+					chunkEnd = end
+				}
+				accumulate(worker, chunkStart, chunkEnd)
+			}
+		}(w, start, end)
+	}
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	switch cfg.aggregation {
+	case SharedSliceAggregation:
+		for _, partialSum := range shared {
+			total += partialSum
+		}
+	case PaddedSliceAggregation:
+		for _, partialSum := range padded {
+			total += partialSum.value
+		}
+	case AtomicAggregation:
+		total = int(atomicSum.Load())
+	case MutexAggregation:
+		total = mutexSum
+	}
+
+	return total, nil
+}
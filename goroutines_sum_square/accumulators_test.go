@@ -0,0 +1,101 @@
+package goroutines_sum_square
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"unsafe"
+)
+
+var aggregations = []Aggregation{
+	ChannelAggregation,
+	SharedSliceAggregation,
+	PaddedSliceAggregation,
+	AtomicAggregation,
+	MutexAggregation,
+}
+
+func TestPaddedIntFillsACacheLine(t *testing.T) {
+	if size := unsafe.Sizeof(paddedInt{}); size != cacheLineSize {
+		t.Errorf("paddedInt is %d bytes, expected %d", size, cacheLineSize)
+	}
+}
+
+func TestAggregations(t *testing.T) {
+	items := RandomArray(123457, -1000, 1000)
+	expected := simpleSumSquare(items)
+
+	for _, aggregation := range aggregations {
+		t.Run(aggregation.String(), func(t *testing.T) {
+			testFramework(t, func(items []int) int {
+				return SumSquare(items, WithAggregation(aggregation), WithThreshold(0), WithWorkers(4))
+			})
+
+			actual := SumSquare(items, WithAggregation(aggregation), WithWorkers(7), WithChunkSize(1000))
+			if actual != expected {
+				t.Errorf("actual %v expected %v", actual, expected)
+			}
+		})
+	}
+}
+
+func TestAggregationsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, aggregation := range aggregations {
+		t.Run(aggregation.String(), func(t *testing.T) {
+			_, err := SumSquareContext(ctx, RandomArray(100000, 0, 1000), WithAggregation(aggregation))
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected %v got %v", context.Canceled, err)
+			}
+		})
+	}
+}
+
+func TestUnknownAggregation(t *testing.T) {
+	_, err := SumSquareContext(context.Background(), RandomArray(100000, 0, 1000), WithAggregation(Aggregation(42)))
+	if err == nil {
+		t.Errorf("expected an error for an unknown aggregation")
+	}
+}
+
+func TestAggregationWithOtherStrategies(t *testing.T) {
+	items := RandomArray(100000, 0, 1000)
+
+	for _, strategy := range []Strategy{Sequential, Chunked, Unsafe, Dynamic, Guided, Stealing} {
+		_, err := SumSquareContext(context.Background(), items, WithStrategy(strategy), WithAggregation(AtomicAggregation))
+		if !errors.Is(err, ErrAggregationStrategy) {
+			t.Errorf("%v: expected %v got %v", strategy, ErrAggregationStrategy, err)
+		}
+
+		// The default aggregation is fine with every strategy
+		if _, err := SumSquareContext(context.Background(), items, WithStrategy(strategy), WithAggregation(ChannelAggregation)); err != nil {
+			t.Errorf("%v: unexpected error %v", strategy, err)
+		}
+	}
+
+	pool := NewPool(2)
+	defer pool.Close()
+	if _, err := SumSquareContext(context.Background(), items, WithPool(pool), WithAggregation(MutexAggregation)); !errors.Is(err, ErrAggregationStrategy) {
+		t.Errorf("pool: expected %v got %v", ErrAggregationStrategy, err)
+	}
+}
+
+func BenchmarkAggregations(b *testing.B) {
+	sizes := [][]int{RandomArray(10000, 0, 10000),
+		RandomArray(100000, 0, 100000),
+		RandomArray(1000000, 0, 1000000),
+	}
+	b.ResetTimer()
+	for _, aggregation := range aggregations {
+		for _, size := range sizes {
+			b.Run(fmt.Sprintf("%v/%d", aggregation, len(size)), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					SumSquare(size, WithAggregation(aggregation), WithThreshold(0))
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package goroutines_sum_square
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// scheduleFor returns the schedule matching the strategy of cfg.
+// PerCPU and Unsafe both use one static block per worker.
+func scheduleFor(cfg config) schedule {
+	switch cfg.strategy {
+	case Chunked, Dynamic:
+		return dynamicSchedule(cfg.chunkSize)
+	case Guided:
+		return guidedSchedule(cfg.chunkSize)
+	case Stealing:
+		return stealingSchedule(cfg.chunkSize)
+	default:
+		return staticSchedule()
+	}
+}
+
+// reduce runs body over [0, n) as configured by cfg and merges the partial results with merge.
+// Inputs up to the threshold, or every input with the Sequential strategy, are handled by a single call to body.
+func reduce[T any](ctx context.Context, n int, cfg config, body func(start, end int) T, merge func(a, b T) T) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	if !cfg.strategy.valid() {
+		return zero, fmt.Errorf("%w %v", ErrUnknownStrategy, cfg.strategy)
+	}
+
+	if cfg.strategy == Sequential || n <= cfg.threshold {
+		return body(0, n), nil
+	}
+
+	return reduceWith(ctx, n, cfg.workers, scheduleFor(cfg), cfg.pool, body, merge)
+}
+
+// reduceWith runs body over [0, n) with the given number of workers, the ranges being handed out by sched.
+// The workers are new goroutines, or jobs of pool when it is not nil.
+// The zero value of T must be the identity of merge, it is the result of a worker that got no range.
+// Partial results are merged in worker order so that the result only depends on the schedule.
+func reduceWith[T any](ctx context.Context, n, workers int, sched schedule, pool *Pool, body func(start, end int) T, merge func(a, b T) T) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	s := sched(n, workers)
+	partials := make([]T, workers) // partials[w] is only written by worker w
+
+	work := func(worker int) {
+		var partial T
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+
+			start, end, ok := s.next(worker)
+			if !ok {
+				break
+			}
+			partial = merge(partial, body(start, end))
+		}
+		partials[worker] = partial
+	}
+
+	if pool != nil {
+		err := pool.Run(ctx, workers, func(worker int) error {
+			work(worker)
+			return nil
+		})
+		if err != nil {
+			return zero, err
+		}
+	} else {
+		wg := sync.WaitGroup{}
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+				work(worker)
+			}(w)
+		}
+		wg.Wait()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	total := zero
+	for _, partial := range partials {
+		total = merge(total, partial)
+	}
+
+	return total, nil
+}
+
+// parallelFor runs body over [0, n) with the workers and schedule of cfg.
+// Unlike reduce it does not apply the threshold, the callers compare it to their own measure of the work.
+func parallelFor(ctx context.Context, n int, cfg config, body func(start, end int)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !cfg.strategy.valid() {
+		return fmt.Errorf("%w %v", ErrUnknownStrategy, cfg.strategy)
+	}
+
+	if cfg.strategy == Sequential {
+		body(0, n)
+		return nil
+	}
+
+	_, err := reduceWith(ctx, n, cfg.workers, scheduleFor(cfg), cfg.pool, func(start, end int) struct{} {
+		body(start, end)
+		return struct{}{}
+	}, func(a, _ struct{}) struct{} { return a })
+	return err
+}
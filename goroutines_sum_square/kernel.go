@@ -0,0 +1,70 @@
+package goroutines_sum_square
+
+// A kernel computes the sum of squares of a slice on a single goroutine.
+// The reducers split the input and call the fastest kernel available on each chunk.
+type kernel struct {
+	name string
+	fn   func(items []int) int
+}
+
+// sumSquareKernel is the fastest kernel supported by the CPU, selected once at startup
+var sumSquareKernel = selectKernel()
+
+// KernelName returns the name of the kernel used by the reducers on this machine
+func KernelName() string {
+	return sumSquareKernel.name
+}
+
+// availableKernels returns the kernels supported by the CPU, from the slowest to the fastest
+func availableKernels() []kernel {
+	kernels := []kernel{
+		{name: "scalar", fn: simpleSumSquare},
+		{name: "unrolled4", fn: unrolled4SumSquare},
+		{name: "unrolled8", fn: unrolled8SumSquare},
+	}
+	return append(kernels, archKernels()...)
+}
+
+func selectKernel() kernel {
+	kernels := availableKernels()
+	return kernels[len(kernels)-1]
+}
+
+// unrolled4SumSquare sums four items per iteration in independent accumulators,
+// so that the CPU can run the multiplications in parallel instead of waiting on a single total.
+func unrolled4SumSquare(items []int) int {
+	var s0, s1, s2, s3 int
+	i := 0
+	for ; i+4 <= len(items); i += 4 {
+		chunk := items[i : i+4 : i+4] // one bounds check for the four loads
+		s0 += chunk[0] * chunk[0]
+		s1 += chunk[1] * chunk[1]
+		s2 += chunk[2] * chunk[2]
+		s3 += chunk[3] * chunk[3]
+	}
+	for ; i < len(items); i++ {
+		s0 += items[i] * items[i]
+	}
+	return s0 + s1 + s2 + s3
+}
+
+// unrolled8SumSquare is unrolled4SumSquare with eight accumulators
+func unrolled8SumSquare(items []int) int {
+	var s0, s1, s2, s3, s4, s5, s6, s7 int
+	i := 0
+	for ; i+8 <= len(items); i += 8 {
+		chunk := items[i : i+8 : i+8]
+		s0 += chunk[0] * chunk[0]
+		s1 += chunk[1] * chunk[1]
+		s2 += chunk[2] * chunk[2]
+		s3 += chunk[3] * chunk[3]
+		s4 += chunk[4] * chunk[4]
+		s5 += chunk[5] * chunk[5]
+		s6 += chunk[6] * chunk[6]
+		s7 += chunk[7] * chunk[7]
+	}
+	for ; i < len(items); i++ {
+		s0 += items[i] * items[i]
+	}
+	return s0 + s1 + s2 + s3 + s4 + s5 + s6 + s7
+}
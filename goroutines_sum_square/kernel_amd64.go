@@ -0,0 +1,47 @@
+//go:build amd64 && !purego
+
+package goroutines_sum_square
+
+// Implemented in kernel_amd64.s
+
+// sumSquareSSE2 squares two items per instruction, SSE2 is available on every amd64 CPU
+func sumSquareSSE2(items []int) int
+
+// sumSquareAVX2 squares four items per instruction
+func sumSquareAVX2(items []int) int
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+func xgetbv() (eax, edx uint32)
+
+func archKernels() []kernel {
+	kernels := []kernel{{name: "sse2", fn: sumSquareSSE2}}
+	if hasAVX2() {
+		kernels = append(kernels, kernel{name: "avx2", fn: sumSquareAVX2})
+	}
+	return kernels
+}
+
+// hasAVX2 reports whether the CPU supports AVX2 and the OS saves the YMM registers
+func hasAVX2() bool {
+	maxID, _, _, _ := cpuid(0, 0)
+	if maxID < 7 {
+		return false
+	}
+
+	_, _, ecx1, _ := cpuid(1, 0)
+	const osxsave, avx = 1 << 27, 1 << 28
+	if ecx1&osxsave == 0 || ecx1&avx == 0 {
+		return false
+	}
+
+	xcr0, _ := xgetbv()
+	const sseState, avxState = 1 << 1, 1 << 2
+	if xcr0&(sseState|avxState) != sseState|avxState {
+		return false
+	}
+
+	_, ebx7, _, _ := cpuid(7, 0)
+	const avx2 = 1 << 5
+	return ebx7&avx2 != 0
+}
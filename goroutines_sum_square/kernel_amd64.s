@@ -0,0 +1,138 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// There is no 64-bit vector multiplication before AVX-512, so each square is built
+// from 32-bit multiplications: with x = hi<<32 + lo, x*x mod 2^64 = lo*lo + (hi*lo)<<33.
+// PMULULQ (PMULUDQ) multiplies the low 32 bits of each 64-bit lane into a 64-bit result.
+
+// func sumSquareSSE2(items []int) int
+TEXT ·sumSquareSSE2(SB), NOSPLIT, $0-32
+	MOVQ items_base+0(FP), SI
+	MOVQ items_len+8(FP), CX
+	PXOR X0, X0 // two accumulators of two lanes
+	PXOR X1, X1
+	XORQ AX, AX // tail accumulator
+
+sse2Loop:
+	CMPQ CX, $4
+	JB   sse2Reduce
+	MOVOU (SI), X2
+	MOVOU 16(SI), X3
+
+	MOVO    X2, X4
+	PSRLQ   $32, X4 // hi
+	PMULULQ X2, X4  // hi*lo
+	PSLLQ   $33, X4
+	PMULULQ X2, X2  // lo*lo
+	PADDQ   X4, X2
+	PADDQ   X2, X0
+
+	MOVO    X3, X5
+	PSRLQ   $32, X5
+	PMULULQ X3, X5
+	PSLLQ   $33, X5
+	PMULULQ X3, X3
+	PADDQ   X5, X3
+	PADDQ   X3, X1
+
+	ADDQ $32, SI
+	SUBQ $4, CX
+	JMP  sse2Loop
+
+sse2Reduce:
+	PADDQ  X1, X0
+	MOVQ   X0, DX
+	ADDQ   DX, AX
+	PSRLDQ $8, X0
+	MOVQ   X0, DX
+	ADDQ   DX, AX
+
+sse2Tail:
+	TESTQ CX, CX
+	JZ    sse2Done
+	MOVQ  (SI), DX
+	IMULQ DX, DX
+	ADDQ  DX, AX
+	ADDQ  $8, SI
+	DECQ  CX
+	JMP   sse2Tail
+
+sse2Done:
+	MOVQ AX, ret+24(FP)
+	RET
+
+// func sumSquareAVX2(items []int) int
+TEXT ·sumSquareAVX2(SB), NOSPLIT, $0-32
+	MOVQ  items_base+0(FP), SI
+	MOVQ  items_len+8(FP), CX
+	VPXOR Y0, Y0, Y0 // two accumulators of four lanes
+	VPXOR Y1, Y1, Y1
+	XORQ  AX, AX
+
+avx2Loop:
+	CMPQ    CX, $8
+	JB      avx2Reduce
+	VMOVDQU (SI), Y2
+	VMOVDQU 32(SI), Y3
+
+	VPSRLQ   $32, Y2, Y4
+	VPMULUDQ Y2, Y4, Y4
+	VPSLLQ   $33, Y4, Y4
+	VPMULUDQ Y2, Y2, Y2
+	VPADDQ   Y4, Y2, Y2
+	VPADDQ   Y2, Y0, Y0
+
+	VPSRLQ   $32, Y3, Y5
+	VPMULUDQ Y3, Y5, Y5
+	VPSLLQ   $33, Y5, Y5
+	VPMULUDQ Y3, Y3, Y3
+	VPADDQ   Y5, Y3, Y3
+	VPADDQ   Y3, Y1, Y1
+
+	ADDQ $64, SI
+	SUBQ $8, CX
+	JMP  avx2Loop
+
+avx2Reduce:
+	VPADDQ       Y1, Y0, Y0
+	VEXTRACTI128 $1, Y0, X1
+	VPADDQ       X1, X0, X0
+	VPSRLDQ      $8, X0, X1
+	VPADDQ       X1, X0, X0
+	VMOVQ        X0, DX
+	ADDQ         DX, AX
+	VZEROUPPER
+
+avx2Tail:
+	TESTQ CX, CX
+	JZ    avx2Done
+	MOVQ  (SI), DX
+	IMULQ DX, DX
+	ADDQ  DX, AX
+	ADDQ  $8, SI
+	DECQ  CX
+	JMP   avx2Tail
+
+avx2Done:
+	MOVQ AX, ret+24(FP)
+	RET
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
+
+// func xgetbv() (eax, edx uint32)
+TEXT ·xgetbv(SB), NOSPLIT, $0-8
+	MOVL $0, CX
+	XGETBV
+	MOVL AX, eax+0(FP)
+	MOVL DX, edx+4(FP)
+	RET
@@ -0,0 +1,8 @@
+//go:build !amd64 || purego
+
+package goroutines_sum_square
+
+// archKernels returns no assembly kernel, the pure Go unrolled kernels are used
+func archKernels() []kernel {
+	return nil
+}
@@ -0,0 +1,93 @@
+package goroutines_sum_square
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestKernels(t *testing.T) {
+	for _, k := range availableKernels() {
+		t.Run(k.name, func(t *testing.T) {
+			testFramework(t, k.fn)
+		})
+	}
+}
+
+// TestKernelsDifferential checks every kernel against the scalar loop, on every length
+// around the unrolling factors and on values whose squares overflow.
+func TestKernelsDifferential(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	inputs := map[string]func(n int) []int{
+		"small": func(n int) []int {
+			items := make([]int, n)
+			for i := range items {
+				items[i] = r.Intn(2001) - 1000
+			}
+			return items
+		},
+		"overflow": func(n int) []int {
+			items := make([]int, n)
+			for i := range items {
+				items[i] = int(r.Uint64())
+			}
+			return items
+		},
+		"extremes": func(n int) []int {
+			items := make([]int, n)
+			for i := range items {
+				items[i] = []int{math.MinInt, math.MaxInt, -1, math.MaxInt >> 31, math.MinInt >> 33}[i%5]
+			}
+			return items
+		},
+	}
+
+	for name, input := range inputs {
+		for n := 0; n <= 67; n++ {
+			// offset the start so that the loads are not always aligned
+			items := input(n + 1)[1:]
+			expected := simpleSumSquare(items)
+			for _, k := range availableKernels() {
+				if actual := k.fn(items); actual != expected {
+					t.Errorf("%s kernel on %d %s items: actual %d expected %d", k.name, n, name, actual, expected)
+				}
+			}
+		}
+	}
+
+	items := RandomArray(1000003, -1<<20, 1<<20)
+	expected := simpleSumSquare(items)
+	for _, k := range availableKernels() {
+		if actual := k.fn(items); actual != expected {
+			t.Errorf("%s kernel on a large input: actual %d expected %d", k.name, actual, expected)
+		}
+	}
+}
+
+func TestSelectedKernel(t *testing.T) {
+	kernels := availableKernels()
+	if expected := kernels[len(kernels)-1].name; KernelName() != expected {
+		t.Errorf("selected kernel %s expected %s", KernelName(), expected)
+	}
+	t.Logf("selected kernel: %s", KernelName())
+}
+
+// BenchmarkKernels reports the throughput of every kernel in GB/s of items read
+func BenchmarkKernels(b *testing.B) {
+	for _, size := range []int{1000, 100000, 10000000} {
+		items := RandomArray(size, 0, 1000)
+		for _, k := range availableKernels() {
+			b.Run(fmt.Sprintf("%s/%d", k.name, size), func(b *testing.B) {
+				b.SetBytes(int64(size) * 8)
+				start := time.Now()
+				for i := 0; i < b.N; i++ {
+					k.fn(items)
+				}
+				elapsed := time.Since(start)
+				b.ReportMetric(float64(b.N)*float64(size*8)/float64(elapsed.Nanoseconds()), "GB/s")
+			})
+		}
+	}
+}
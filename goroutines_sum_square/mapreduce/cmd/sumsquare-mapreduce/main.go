@@ -0,0 +1,63 @@
+// Command sumsquare-mapreduce runs either a sum of squares worker or a coordinator.
+//
+// Start a few workers, then a coordinator using them:
+//
+//	sumsquare-mapreduce -worker 127.0.0.1:7001 &
+//	sumsquare-mapreduce -worker 127.0.0.1:7002 &
+//	sumsquare-mapreduce -workers 127.0.0.1:7001,127.0.0.1:7002 -size 10000000
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/corentings/goTeaching/goroutines_sum_square"
+	"github.com/corentings/goTeaching/goroutines_sum_square/mapreduce"
+)
+
+func main() {
+	workerAddr := flag.String("worker", "", "run a worker listening on this address")
+	workers := flag.String("workers", "", "comma separated addresses of the workers used by the coordinator")
+	size := flag.Int("size", 1000000, "number of random items summed by the coordinator")
+	chunkSize := flag.Int("chunk", 10000, "number of items sent to a worker at once")
+	flag.Parse()
+
+	if *workerAddr != "" {
+		runWorker(*workerAddr)
+		return
+	}
+
+	if *workers == "" {
+		log.Fatal("either -worker or -workers is required")
+	}
+	runCoordinator(strings.Split(*workers, ","), *size, *chunkSize)
+}
+
+func runWorker(addr string) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Worker listening on %s", listener.Addr())
+	if err := mapreduce.NewServer().Serve(listener); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runCoordinator(addrs []string, size, chunkSize int) {
+	items := goroutines_sum_square.RandomArray(size, 0, 1000)
+	coordinator := mapreduce.Coordinator{Addrs: addrs, ChunkSize: chunkSize}
+
+	start := time.Now()
+	total, err := coordinator.SumSquare(context.Background(), items)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Sum of squares of %d items: %d in %s (local: %d)", size, total, time.Since(start), goroutines_sum_square.SumSquare(items))
+}
@@ -0,0 +1,183 @@
+package mapreduce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"sync"
+)
+
+// ErrNoWorkers is returned when every worker died before all the chunks were summed
+var ErrNoWorkers = errors.New("mapreduce: no worker left")
+
+// Coordinator splits the input into chunks and dispatches them to the workers listening on Addrs
+type Coordinator struct {
+	// Addrs are the tcp addresses of the workers
+	Addrs []string
+	// ChunkSize is the number of items sent in a single call, 10000 by default
+	ChunkSize int
+	// MaxAttempts is the number of times a chunk is tried before giving up, the number of workers by default
+	MaxAttempts int
+}
+
+type chunk struct {
+	index    int
+	start    int
+	end      int
+	attempts int
+}
+
+// SumSquare computes the sum of squares of items on the workers.
+// When a worker dies, its chunk is put back in the queue and handled by another worker.
+func (c *Coordinator) SumSquare(ctx context.Context, items []int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if len(c.Addrs) == 0 {
+		return 0, ErrNoWorkers
+	}
+
+	chunkSize := c.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 10000
+	}
+	maxAttempts := c.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = len(c.Addrs)
+	}
+
+	// Fill the queue, it is big enough to hold every chunk so that requeueing never blocks
+	totalChunks := (len(items) + chunkSize - 1) / chunkSize
+	if totalChunks == 0 {
+		return 0, nil
+	}
+	queue := make(chan chunk, totalChunks)
+	for i := 0; i < totalChunks; i++ {
+		end := (i + 1) * chunkSize
+		if end > len(items) {
+			end = len(items)
+		}
+		queue <- chunk{index: i, start: i * chunkSize, end: end}
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		total     int
+		remaining = totalChunks
+		failure   error
+		done      = make(chan struct{}) // closed once every chunk is summed or a chunk failed too many times
+	)
+
+	// finish records the result of a chunk, err is non nil when the chunk can't be retried anymore
+	finish := func(sum int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if remaining == 0 || failure != nil {
+			return
+		}
+		if err != nil {
+			failure = err
+			close(done)
+			return
+		}
+
+		total += sum
+		remaining--
+		if remaining == 0 {
+			close(done)
+		}
+	}
+
+	// retry puts the chunk back in the queue, or gives up once it was tried maxAttempts times
+	retry := func(ch chunk, err error) {
+		ch.attempts++
+		if ch.attempts >= maxAttempts {
+			finish(0, fmt.Errorf("mapreduce: chunk %d failed %d times: %w", ch.index, ch.attempts, err))
+			return
+		}
+		queue <- ch
+	}
+
+	wg := sync.WaitGroup{}
+	for _, addr := range c.Addrs {
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			runWorker(ctx, addr, items, queue, finish, retry)
+		}(addr)
+	}
+
+	allDead := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(allDead)
+	}()
+
+	select {
+	case <-done:
+	case <-allDead:
+	case <-ctx.Done():
+	}
+	cancel()
+	<-allDead // every worker goroutine has returned
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	switch {
+	case failure != nil:
+		return 0, failure
+	case remaining == 0:
+		return total, nil
+	case parent.Err() != nil:
+		return 0, parent.Err()
+	default:
+		return 0, ErrNoWorkers
+	}
+}
+
+// runWorker sends chunks to the worker at addr until the queue is drained, the context is cancelled or the worker dies
+func runWorker(ctx context.Context, addr string, items []int, queue chan chunk, finish func(int, error), retry func(chunk, error)) {
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return // the worker is dead or unreachable, the others will do its share
+	}
+	client := rpc.NewClient(conn)
+	defer client.Close()
+
+	for {
+		var ch chunk
+		select {
+		case <-ctx.Done():
+			return
+		case ch = <-queue:
+		}
+
+		var reply ChunkReply
+		call := client.Go(serviceMethod, ChunkArgs{Index: ch.index, Items: items[ch.start:ch.end]}, &reply, make(chan *rpc.Call, 1))
+
+		select {
+		case <-ctx.Done():
+			return // the chunk is not needed anymore
+		case <-call.Done:
+		}
+
+		if call.Error != nil {
+			retry(ch, call.Error)
+			if _, ok := call.Error.(rpc.ServerError); ok {
+				continue // the worker answered with an error, it is still alive
+			}
+			return // the connection is broken, consider the worker dead
+		}
+
+		finish(reply.Sum, nil)
+	}
+}
@@ -0,0 +1,173 @@
+package mapreduce
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/rpc"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/corentings/goTeaching/goroutines_sum_square"
+)
+
+// startWorkers starts n in-process workers on ephemeral ports
+func startWorkers(t *testing.T, n int) ([]*Server, []string) {
+	t.Helper()
+
+	servers := make([]*Server, n)
+	addrs := make([]string, n)
+	for i := 0; i < n; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		server := NewServer()
+		servers[i] = server
+		addrs[i] = listener.Addr().String()
+		go func() {
+			if err := server.Serve(listener); err != nil {
+				t.Errorf("serve: %v", err)
+			}
+		}()
+		t.Cleanup(func() { _ = server.Close() })
+	}
+
+	return servers, addrs
+}
+
+// dyingWorker drops its connection on the first chunk it receives, as a crashing process would
+type dyingWorker struct {
+	mu       sync.Mutex
+	conns    []net.Conn
+	received atomic.Int32
+}
+
+func (w *dyingWorker) SumSquare(_ ChunkArgs, _ *ChunkReply) error {
+	w.received.Add(1)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, conn := range w.conns {
+		_ = conn.Close()
+	}
+	return nil
+}
+
+func startDyingWorker(t *testing.T) (*dyingWorker, string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	worker := &dyingWorker{}
+	server := rpc.NewServer()
+	if err := server.RegisterName(ServiceName, worker); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			worker.mu.Lock()
+			worker.conns = append(worker.conns, conn)
+			worker.mu.Unlock()
+			go server.ServeConn(conn)
+		}
+	}()
+
+	return worker, listener.Addr().String()
+}
+
+func TestCoordinatorSumSquare(t *testing.T) {
+	_, addrs := startWorkers(t, 3)
+	items := goroutines_sum_square.RandomArray(123457, 0, 1000)
+	expected := goroutines_sum_square.SumSquare(items, goroutines_sum_square.WithStrategy(goroutines_sum_square.Sequential))
+
+	for _, chunkSize := range []int{0, 1000, 1000000} {
+		coordinator := Coordinator{Addrs: addrs, ChunkSize: chunkSize}
+		actual, err := coordinator.SumSquare(context.Background(), items)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if actual != expected {
+			t.Errorf("chunk size %d: actual %v expected %v", chunkSize, actual, expected)
+		}
+	}
+
+	coordinator := Coordinator{Addrs: addrs}
+	if actual, err := coordinator.SumSquare(context.Background(), nil); actual != 0 || err != nil {
+		t.Errorf("empty input: actual %v %v expected 0", actual, err)
+	}
+}
+
+func TestCoordinatorSkipsDeadWorkers(t *testing.T) {
+	servers, addrs := startWorkers(t, 3)
+	_ = servers[0].Close()
+
+	items := goroutines_sum_square.RandomArray(50000, 0, 1000)
+	coordinator := Coordinator{Addrs: append(addrs, "127.0.0.1:1"), ChunkSize: 1000}
+
+	actual, err := coordinator.SumSquare(context.Background(), items)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if expected := goroutines_sum_square.SumSquare(items); actual != expected {
+		t.Errorf("actual %v expected %v", actual, expected)
+	}
+}
+
+func TestCoordinatorRetriesChunksOfDyingWorkers(t *testing.T) {
+	_, addrs := startWorkers(t, 2)
+	dying, dyingAddr := startDyingWorker(t)
+
+	items := goroutines_sum_square.RandomArray(50000, 0, 1000)
+	coordinator := Coordinator{Addrs: append([]string{dyingAddr}, addrs...), ChunkSize: 100}
+
+	actual, err := coordinator.SumSquare(context.Background(), items)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if expected := goroutines_sum_square.SumSquare(items); actual != expected {
+		t.Errorf("actual %v expected %v", actual, expected)
+	}
+	if dying.received.Load() == 0 {
+		t.Errorf("the dying worker never received a chunk")
+	}
+}
+
+func TestCoordinatorWithoutWorkers(t *testing.T) {
+	servers, addrs := startWorkers(t, 2)
+	for _, server := range servers {
+		_ = server.Close()
+	}
+
+	coordinator := Coordinator{Addrs: addrs}
+	if _, err := coordinator.SumSquare(context.Background(), []int{1, 2, 3}); !errors.Is(err, ErrNoWorkers) {
+		t.Errorf("expected %v got %v", ErrNoWorkers, err)
+	}
+
+	_, dyingAddr := startDyingWorker(t)
+	coordinator = Coordinator{Addrs: []string{dyingAddr}}
+	if _, err := coordinator.SumSquare(context.Background(), []int{1, 2, 3}); err == nil {
+		t.Errorf("expected an error when the only worker dies")
+	}
+}
+
+func TestCoordinatorCancelled(t *testing.T) {
+	_, addrs := startWorkers(t, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	coordinator := Coordinator{Addrs: addrs}
+	if _, err := coordinator.SumSquare(ctx, []int{1, 2, 3}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v got %v", context.Canceled, err)
+	}
+}
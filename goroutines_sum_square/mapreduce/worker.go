@@ -0,0 +1,125 @@
+// Package mapreduce distributes the sum of squares over worker processes with net/rpc.
+//
+// A Coordinator splits the input into chunks and sends them to the workers (map),
+// then adds up the partial sums (reduce). Chunks handled by a worker that dies are retried on the others.
+package mapreduce
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"sync"
+
+	"github.com/corentings/goTeaching/goroutines_sum_square"
+)
+
+// ServiceName is the name under which the Worker is registered on the rpc server
+const ServiceName = "SumSquareWorker"
+
+// serviceMethod is the method called by the coordinator for each chunk
+const serviceMethod = ServiceName + ".SumSquare"
+
+// ChunkArgs is the request sent for a chunk
+type ChunkArgs struct {
+	Index int   // index of the chunk, for logging and debugging
+	Items []int // items of the chunk
+}
+
+// ChunkReply is the answer of a worker for a chunk
+type ChunkReply struct {
+	Sum int
+}
+
+// Worker is the rpc service computing the partial sums
+type Worker struct{}
+
+// SumSquare computes the sum of squares of a chunk with the goroutines of the worker process
+func (w *Worker) SumSquare(args ChunkArgs, reply *ChunkReply) error {
+	reply.Sum = goroutines_sum_square.SumSquare(args.Items)
+	return nil
+}
+
+// Server serves a Worker and keeps track of its connections so that Close can stop it like a dead process
+type Server struct {
+	rpc *rpc.Server
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// NewServer returns a Server ready to serve a Worker
+func NewServer() *Server {
+	server := rpc.NewServer()
+	if err := server.RegisterName(ServiceName, &Worker{}); err != nil {
+		panic(err) // Worker is always a valid service
+	}
+
+	return &Server{rpc: server, conns: make(map[net.Conn]struct{})}
+}
+
+// Serve accepts connections on l and serves each of them in its own goroutine.
+// It returns nil once the server is closed, or the error returned by Accept.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return l.Close()
+	}
+	s.listener = l
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) && s.isClosed() {
+				return nil
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = conn.Close()
+			continue
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		go func() {
+			s.rpc.ServeConn(conn) // returns once the connection is closed
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// Close stops the listener and drops every open connection, the coordinators see the worker die
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+
+	return err
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
@@ -0,0 +1,200 @@
+package goroutines_sum_square
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrDimensionMismatch is returned when the dimensions of the operands of a matrix product don't match
+var ErrDimensionMismatch = errors.New("goroutines_sum_square: dimension mismatch")
+
+// Matrix is a dense matrix stored in row-major order: the element (i, j) is Data[i*Cols+j]
+type Matrix[T Number] struct {
+	Rows int
+	Cols int
+	Data []T
+}
+
+// NewMatrix returns a rows x cols matrix filled with zeros
+func NewMatrix[T Number](rows, cols int) Matrix[T] {
+	return Matrix[T]{Rows: rows, Cols: cols, Data: make([]T, rows*cols)}
+}
+
+// NewMatrixFrom wraps data, which must hold rows*cols elements in row-major order
+func NewMatrixFrom[T Number](rows, cols int, data []T) (Matrix[T], error) {
+	if rows < 0 || cols < 0 || len(data) != rows*cols {
+		return Matrix[T]{}, fmt.Errorf("%w: %d elements for a %dx%d matrix", ErrDimensionMismatch, len(data), rows, cols)
+	}
+	return Matrix[T]{Rows: rows, Cols: cols, Data: data}, nil
+}
+
+// At returns the element at row i and column j
+func (m Matrix[T]) At(i, j int) T {
+	return m.Data[i*m.Cols+j]
+}
+
+// Set sets the element at row i and column j
+func (m Matrix[T]) Set(i, j int, value T) {
+	m.Data[i*m.Cols+j] = value
+}
+
+// Row returns the row i, it shares its memory with the matrix
+func (m Matrix[T]) Row(i int) []T {
+	return m.Data[i*m.Cols : (i+1)*m.Cols]
+}
+
+func checkMatMul[T Number](a, b Matrix[T]) error {
+	if a.Cols != b.Rows {
+		return fmt.Errorf("%w: %dx%d times %dx%d", ErrDimensionMismatch, a.Rows, a.Cols, b.Rows, b.Cols)
+	}
+	return nil
+}
+
+// MatMul returns a*b with the textbook triple loop.
+// The inner loop walks a column of b, jumping a whole row of b in memory at each step.
+func MatMul[T Number](a, b Matrix[T]) (Matrix[T], error) {
+	if err := checkMatMul(a, b); err != nil {
+		return Matrix[T]{}, err
+	}
+
+	c := NewMatrix[T](a.Rows, b.Cols)
+	for i := 0; i < a.Rows; i++ {
+		for j := 0; j < b.Cols; j++ {
+			var total T
+			for k := 0; k < a.Cols; k++ {
+				total += a.Data[i*a.Cols+k] * b.Data[k*b.Cols+j]
+			}
+			c.Data[i*c.Cols+j] = total
+		}
+	}
+
+	return c, nil
+}
+
+// BlockedMatMul returns a*b computed tile by tile, so that the tiles of a, b and c being used stay in cache.
+// A blockSize <= 0 uses the default of 64.
+func BlockedMatMul[T Number](a, b Matrix[T], blockSize int) (Matrix[T], error) {
+	if err := checkMatMul(a, b); err != nil {
+		return Matrix[T]{}, err
+	}
+	if blockSize <= 0 {
+		blockSize = newConfig().blockSize
+	}
+
+	c := NewMatrix[T](a.Rows, b.Cols)
+	matMulRows(a, b, c, 0, a.Rows, blockSize)
+
+	return c, nil
+}
+
+// ParallelMatMul returns a*b, the rows of c are split into bands of WithBlockSize rows
+// and the bands are shared between the workers, each running the tiled kernel of BlockedMatMul.
+// Products with at most WithThreshold multiply-adds run on the calling goroutine.
+func ParallelMatMul[T Number](a, b Matrix[T], opts ...Option) (Matrix[T], error) {
+	if err := checkMatMul(a, b); err != nil {
+		return Matrix[T]{}, err
+	}
+
+	cfg := newConfig(opts...)
+	c := NewMatrix[T](a.Rows, b.Cols)
+
+	if a.Rows*a.Cols*b.Cols <= cfg.threshold {
+		matMulRows(a, b, c, 0, a.Rows, cfg.blockSize)
+		return c, nil
+	}
+
+	bands := (a.Rows + cfg.blockSize - 1) / cfg.blockSize
+	cfg.chunkSize = 1 // a chunk of the schedule is one band
+	err := parallelFor(context.Background(), bands, cfg, func(start, end int) {
+		endRow := end * cfg.blockSize
+		if endRow > a.Rows {
+			endRow = a.Rows
+		}
+		matMulRows(a, b, c, start*cfg.blockSize, endRow, cfg.blockSize)
+	})
+
+	return c, err
+}
+
+// matMulRows computes the rows [rowStart, rowEnd) of c = a*b tile by tile.
+// The i, k, j loop order makes the inner loop walk rows of b and c contiguously.
+func matMulRows[T Number](a, b, c Matrix[T], rowStart, rowEnd, blockSize int) {
+	n, p := a.Cols, b.Cols
+
+	for ii := rowStart; ii < rowEnd; ii += blockSize {
+		iEnd := minInt(ii+blockSize, rowEnd)
+		for kk := 0; kk < n; kk += blockSize {
+			kEnd := minInt(kk+blockSize, n)
+			for jj := 0; jj < p; jj += blockSize {
+				jEnd := minInt(jj+blockSize, p)
+
+				for i := ii; i < iEnd; i++ {
+					cRow := c.Data[i*p : (i+1)*p]
+					for k := kk; k < kEnd; k++ {
+						aik := a.Data[i*n+k]
+						bRow := b.Data[k*p : (k+1)*p]
+						for j := jj; j < jEnd; j++ {
+							cRow[j] += aik * bRow[j]
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// MatVec returns the product of m by the column vector v
+func MatVec[T Number](m Matrix[T], v []T) ([]T, error) {
+	if m.Cols != len(v) {
+		return nil, fmt.Errorf("%w: %dx%d times %d", ErrDimensionMismatch, m.Rows, m.Cols, len(v))
+	}
+
+	result := make([]T, m.Rows)
+	matVecRows(m, v, result, 0, m.Rows)
+
+	return result, nil
+}
+
+// ParallelMatVec returns the product of m by the column vector v, the rows being shared between the workers.
+// A chunk holds as many rows as needed to reach WithChunkSize elements.
+// Matrices with at most WithThreshold elements are handled on the calling goroutine.
+func ParallelMatVec[T Number](m Matrix[T], v []T, opts ...Option) ([]T, error) {
+	if m.Cols != len(v) {
+		return nil, fmt.Errorf("%w: %dx%d times %d", ErrDimensionMismatch, m.Rows, m.Cols, len(v))
+	}
+
+	cfg := newConfig(opts...)
+	result := make([]T, m.Rows)
+
+	if m.Rows*m.Cols <= cfg.threshold {
+		matVecRows(m, v, result, 0, m.Rows)
+		return result, nil
+	}
+
+	if m.Cols > 0 {
+		cfg.chunkSize = (cfg.chunkSize + m.Cols - 1) / m.Cols // rows per chunk
+	}
+	err := parallelFor(context.Background(), m.Rows, cfg, func(start, end int) {
+		matVecRows(m, v, result, start, end)
+	})
+
+	return result, err
+}
+
+func matVecRows[T Number](m Matrix[T], v []T, result []T, rowStart, rowEnd int) {
+	for i := rowStart; i < rowEnd; i++ {
+		var total T
+		for j, value := range m.Row(i) {
+			total += value * v[j]
+		}
+		result[i] = total
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
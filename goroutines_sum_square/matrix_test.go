@@ -0,0 +1,157 @@
+package goroutines_sum_square
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func randomMatrix(rows, cols int) Matrix[int] {
+	m, _ := NewMatrixFrom(rows, cols, RandomArray(rows*cols, -100, 100))
+	return m
+}
+
+func TestMatMul(t *testing.T) {
+	a, _ := NewMatrixFrom(2, 3, []int{1, 2, 3, 4, 5, 6})
+	b, _ := NewMatrixFrom(3, 2, []int{7, 8, 9, 10, 11, 12})
+	expected := []int{58, 64, 139, 154}
+
+	products := []struct {
+		name    string
+		product func(a, b Matrix[int]) (Matrix[int], error)
+	}{
+		{name: "naive", product: MatMul[int]},
+		{name: "blocked", product: func(a, b Matrix[int]) (Matrix[int], error) { return BlockedMatMul(a, b, 2) }},
+		{name: "parallel", product: func(a, b Matrix[int]) (Matrix[int], error) {
+			return ParallelMatMul(a, b, WithThreshold(0), WithBlockSize(1), WithWorkers(2))
+		}},
+	}
+
+	for _, product := range products {
+		t.Run(product.name, func(t *testing.T) {
+			c, err := product.product(a, b)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if c.Rows != 2 || c.Cols != 2 || !reflect.DeepEqual(c.Data, expected) {
+				t.Errorf("actual %v expected %v", c, expected)
+			}
+
+			if _, err := product.product(a, a); !errors.Is(err, ErrDimensionMismatch) {
+				t.Errorf("expected %v got %v", ErrDimensionMismatch, err)
+			}
+		})
+	}
+}
+
+func TestMatMulAgainstNaive(t *testing.T) {
+	shapes := []struct{ rows, inner, cols int }{{1, 1, 1}, {17, 33, 9}, {64, 64, 64}, {130, 70, 101}}
+
+	for _, shape := range shapes {
+		a, b := randomMatrix(shape.rows, shape.inner), randomMatrix(shape.inner, shape.cols)
+		expected, _ := MatMul(a, b)
+
+		for _, blockSize := range []int{1, 7, 32, 256} {
+			t.Run(fmt.Sprintf("%dx%dx%d/%d", shape.rows, shape.inner, shape.cols, blockSize), func(t *testing.T) {
+				blocked, _ := BlockedMatMul(a, b, blockSize)
+				if !reflect.DeepEqual(blocked, expected) {
+					t.Errorf("blocked product differs from the naive one")
+				}
+
+				for _, strategy := range []Strategy{PerCPU, Dynamic, Stealing} {
+					parallel, err := ParallelMatMul(a, b, WithThreshold(0), WithBlockSize(blockSize), WithWorkers(3), WithStrategy(strategy))
+					if err != nil {
+						t.Fatalf("unexpected error %v", err)
+					}
+					if !reflect.DeepEqual(parallel, expected) {
+						t.Errorf("%v parallel product differs from the naive one", strategy)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestMatVec(t *testing.T) {
+	m, _ := NewMatrixFrom(2, 3, []float64{1, 2, 3, 4, 5, 6})
+	v := []float64{1, 0, -1}
+
+	actual, err := MatVec(m, v)
+	if err != nil || !reflect.DeepEqual(actual, []float64{-2, -2}) {
+		t.Errorf("actual %v %v expected [-2 -2]", actual, err)
+	}
+
+	if _, err := MatVec(m, []float64{1}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected %v got %v", ErrDimensionMismatch, err)
+	}
+	if _, err := ParallelMatVec(m, []float64{1}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected %v got %v", ErrDimensionMismatch, err)
+	}
+
+	big := randomMatrix(301, 211)
+	vector := RandomArray(211, -100, 100)
+	expected, _ := MatVec(big, vector)
+	for _, strategy := range []Strategy{Sequential, PerCPU, Dynamic, Guided, Stealing} {
+		parallel, err := ParallelMatVec(big, vector, WithThreshold(0), WithChunkSize(500), WithWorkers(4), WithStrategy(strategy))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !reflect.DeepEqual(parallel, expected) {
+			t.Errorf("%v parallel product differs from the sequential one", strategy)
+		}
+	}
+}
+
+func TestNewMatrixFrom(t *testing.T) {
+	if _, err := NewMatrixFrom(2, 2, []int{1, 2, 3}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected %v got %v", ErrDimensionMismatch, err)
+	}
+
+	m := NewMatrix[int](2, 3)
+	m.Set(1, 2, 42)
+	if m.At(1, 2) != 42 || m.Row(1)[2] != 42 || m.Data[5] != 42 {
+		t.Errorf("Set and At don't agree: %v", m)
+	}
+}
+
+func BenchmarkMatMul(b *testing.B) {
+	for _, size := range []int{64, 256, 512} {
+		x, y := randomMatrix(size, size), randomMatrix(size, size)
+
+		b.Run(fmt.Sprintf("naive/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = MatMul(x, y)
+			}
+		})
+		for _, blockSize := range []int{16, 64} {
+			b.Run(fmt.Sprintf("blocked-%d/%d", blockSize, size), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_, _ = BlockedMatMul(x, y, blockSize)
+				}
+			})
+			b.Run(fmt.Sprintf("parallel-%d/%d", blockSize, size), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_, _ = ParallelMatMul(x, y, WithBlockSize(blockSize))
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkMatVec(b *testing.B) {
+	for _, size := range []int{256, 1024, 4096} {
+		m, v := randomMatrix(size, size), RandomArray(size, -100, 100)
+
+		b.Run(fmt.Sprintf("sequential/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = MatVec(m, v)
+			}
+		})
+		b.Run(fmt.Sprintf("parallel/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = ParallelMatVec(m, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package goroutines_sum_square
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// ErrPoolClosed is returned when a job is submitted to a closed Pool
+var ErrPoolClosed = errors.New("goroutines_sum_square: pool closed")
+
+// PanicError is returned instead of crashing the program when a job of a Pool panics
+type PanicError struct {
+	Value any    // value passed to panic
+	Stack []byte // stack of the panicking goroutine
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("goroutines_sum_square: job panicked: %v", e.Value)
+}
+
+// Pool is a set of long-lived worker goroutines that run the jobs of many reductions.
+// It saves the cost of starting goroutines on every call, which matters for mid-size inputs.
+type Pool struct {
+	jobs    chan func()
+	workers int
+	wg      sync.WaitGroup
+
+	mu     sync.RWMutex // held for reading while submitting, for writing while closing
+	closed bool
+}
+
+// NewPool starts a pool of workers goroutines, runtime.NumCPU() if workers <= 0.
+// The pool must be closed once it is not needed anymore.
+func NewPool(workers int) *Pool {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	p := &Pool{jobs: make(chan func()), workers: workers}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer p.wg.Done()
+			for job := range p.jobs {
+				job()
+			}
+		}()
+	}
+
+	return p
+}
+
+// Workers returns the number of goroutines of the pool
+func (p *Pool) Workers() int {
+	return p.workers
+}
+
+// Run calls fn(i) for every i in [0, n) on the workers of the pool and waits for the calls to return.
+// It returns the first error, a *PanicError if fn panicked, ctx.Err() if the context is done before
+// every job started, or ErrPoolClosed.
+// fn must not call Run on the same pool, it could wait for itself.
+func (p *Pool) Run(ctx context.Context, n int, fn func(i int) error) error {
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return ErrPoolClosed
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	setErr := func(err error) {
+		errOnce.Do(func() { firstErr = err })
+	}
+
+submit:
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		job := func() {
+			defer wg.Done()
+			defer func() {
+				if value := recover(); value != nil {
+					setErr(&PanicError{Value: value, Stack: debug.Stack()})
+				}
+			}()
+
+			if ctx.Err() != nil {
+				return
+			}
+			if err := fn(i); err != nil {
+				setErr(err)
+			}
+		}
+
+		select {
+		case p.jobs <- job:
+		case <-ctx.Done():
+			wg.Done()
+			break submit
+		}
+	}
+	p.mu.RUnlock()
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// Close stops the workers once the running jobs are done, it is safe to call it several times
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.jobs)
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	return nil
+}
+
+// WithPool runs the reductions on the workers of pool instead of starting new goroutines on each call.
+// The strategy still decides how the input is split, SumSquare goes through the same engine as Dot.
+func WithPool(pool *Pool) Option {
+	return func(c *config) {
+		c.pool = pool
+	}
+}
@@ -0,0 +1,146 @@
+package goroutines_sum_square
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPoolRun(t *testing.T) {
+	pool := NewPool(4)
+	defer pool.Close()
+
+	var calls [100]int32
+	if err := pool.Run(context.Background(), len(calls), func(i int) error {
+		atomic.AddInt32(&calls[i], 1)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for i, c := range calls {
+		if c != 1 {
+			t.Errorf("job %d ran %d times", i, c)
+		}
+	}
+
+	errJob := errors.New("job failed")
+	if err := pool.Run(context.Background(), 10, func(i int) error {
+		if i == 3 {
+			return errJob
+		}
+		return nil
+	}); !errors.Is(err, errJob) {
+		t.Errorf("expected %v got %v", errJob, err)
+	}
+}
+
+func TestPoolPanicIsolation(t *testing.T) {
+	pool := NewPool(2)
+	defer pool.Close()
+
+	err := pool.Run(context.Background(), 10, func(i int) error {
+		if i == 5 {
+			panic("boom")
+		}
+		return nil
+	})
+
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+		t.Fatalf("expected a PanicError for boom, got %v", err)
+	}
+
+	// the workers survived the panic
+	if total := SumSquare(RandomArray(100000, 0, 100), WithPool(pool)); total <= 0 {
+		t.Errorf("the pool doesn't work anymore after a panic")
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	pool := NewPool(8)
+	if pool.Workers() != 8 {
+		t.Errorf("expected 8 workers got %d", pool.Workers())
+	}
+	if err := pool.Close(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := pool.Close(); err != nil {
+		t.Errorf("closing twice should be a no-op, got %v", err)
+	}
+
+	if err := pool.Run(context.Background(), 1, func(int) error { return nil }); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("expected %v got %v", ErrPoolClosed, err)
+	}
+	if _, err := SumSquareContext(context.Background(), RandomArray(100000, 0, 100), WithPool(pool)); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("expected %v got %v", ErrPoolClosed, err)
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("Close left %d goroutines running", after-before)
+	}
+}
+
+func TestPoolCancelled(t *testing.T) {
+	pool := NewPool(2)
+	defer pool.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ran := int32(0)
+	err := pool.Run(ctx, 1000, func(int) error {
+		atomic.AddInt32(&ran, 1)
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || ran != 0 {
+		t.Errorf("expected %v without running any job, got %v after %d jobs", context.Canceled, err, ran)
+	}
+}
+
+func TestPooledReductions(t *testing.T) {
+	pool := NewPool(3)
+	defer pool.Close()
+
+	items := RandomArray(123457, -1000, 1000)
+	expected := simpleSumSquare(items)
+
+	for _, strategy := range []Strategy{PerCPU, Dynamic, Guided, Stealing} {
+		t.Run(strategy.String(), func(t *testing.T) {
+			testFramework(t, func(items []int) int {
+				return SumSquare(items, WithPool(pool), WithStrategy(strategy), WithThreshold(0))
+			})
+
+			if actual := SumSquare(items, WithPool(pool), WithStrategy(strategy)); actual != expected {
+				t.Errorf("actual %v expected %v", actual, expected)
+			}
+			if actual, _ := Dot(items, items, WithPool(pool), WithStrategy(strategy)); actual != expected {
+				t.Errorf("dot actual %v expected %v", actual, expected)
+			}
+		})
+	}
+}
+
+func BenchmarkPooledVsSpawned(b *testing.B) {
+	pool := NewPool(0)
+	defer pool.Close()
+
+	for _, size := range []int{10000, 100000, 1000000} {
+		items := RandomArray(size, 0, 1000)
+
+		b.Run(fmt.Sprintf("spawned/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				SumSquare(items, WithThreshold(0))
+			}
+		})
+		b.Run(fmt.Sprintf("pooled/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				SumSquare(items, WithThreshold(0), WithPool(pool))
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package goroutines_sum_square
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInvalidHistogram is returned by Histogram for less than one bin or an empty range
+var ErrInvalidHistogram = errors.New("goroutines_sum_square: invalid histogram bins or range")
+
+// InclusiveScan returns the running sums of items: out[i] = items[0] + ... + items[i].
+// The parallel version uses the two-pass block algorithm, blocks being WithChunkSize items long.
+func InclusiveScan[T Number](items []T, opts ...Option) ([]T, error) {
+	out := make([]T, len(items))
+	if err := scan(items, out, newConfig(opts...), true); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExclusiveScan returns the running sums of items before each index: out[0] = 0 and out[i] = items[0] + ... + items[i-1]
+func ExclusiveScan[T Number](items []T, opts ...Option) ([]T, error) {
+	out := make([]T, len(items))
+	if err := scan(items, out, newConfig(opts...), false); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// scanBlock writes the scan of items into out starting from offset, and returns offset plus the sum of items
+func scanBlock[T Number](items, out []T, offset T, inclusive bool) T {
+	total := offset
+	for i, item := range items {
+		if inclusive {
+			total += item
+			out[i] = total
+		} else {
+			out[i] = total
+			total += item
+		}
+	}
+	return total
+}
+
+// scan writes the scan of items into out, which is only complete when no error is returned
+func scan[T Number](items, out []T, cfg config, inclusive bool) error {
+	n := len(items)
+	if cfg.strategy.valid() && (cfg.strategy == Sequential || n <= cfg.threshold) {
+		scanBlock(items, out, 0, inclusive)
+		return nil
+	}
+
+	blockSize := cfg.chunkSize
+	blocks := (n + blockSize - 1) / blockSize
+	blockEnd := func(b int) int {
+		if end := (b + 1) * blockSize; end < n {
+			return end
+		}
+		return n
+	}
+
+	// Pass 1: scan every block on its own and keep its total
+	blockSums := make([]T, blocks)
+	cfg.chunkSize = 1 // a chunk of the schedule is one block
+	err := parallelFor(context.Background(), blocks, cfg, func(start, end int) {
+		for b := start; b < end; b++ {
+			blockSums[b] = scanBlock(items[b*blockSize:blockEnd(b)], out[b*blockSize:blockEnd(b)], 0, inclusive)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// The offset of a block is the exclusive scan of the block totals, there are few of them
+	scanBlock(blockSums, blockSums, 0, false)
+
+	// Pass 2: shift every block but the first one by its offset
+	return parallelFor(context.Background(), blocks, cfg, func(start, end int) {
+		for b := start; b < end; b++ {
+			if b == 0 {
+				continue
+			}
+			offset := blockSums[b]
+			block := out[b*blockSize : blockEnd(b)]
+			for i := range block {
+				block[i] += offset
+			}
+		}
+	})
+}
+
+// Histogram counts the items falling in each of bins equal-width bins covering [min, max].
+// The last bin includes max, items outside of the range and NaN are not counted.
+// Every worker fills its own bins, which are added together at the end.
+func Histogram[T Number](items []T, bins int, min, max T, opts ...Option) ([]int, error) {
+	if bins < 1 || !(min < max) {
+		return nil, ErrInvalidHistogram
+	}
+
+	low, width := float64(min), (float64(max)-float64(min))/float64(bins)
+
+	counts, err := reduce(context.Background(), len(items), newConfig(opts...), func(start, end int) []int {
+		local := make([]int, bins)
+		for _, item := range items[start:end] {
+			if item != item || item < min || item > max {
+				continue // NaN compares false to both bounds
+			}
+			bin := int((float64(item) - low) / width)
+			if bin >= bins {
+				bin = bins - 1 // max and rounding errors
+			}
+			local[bin]++
+		}
+		return local
+	}, func(a, b []int) []int {
+		if a == nil {
+			return b
+		}
+		for i := range b {
+			a[i] += b[i]
+		}
+		return a
+	})
+	if err != nil {
+		return nil, err
+	}
+	if counts == nil {
+		counts = make([]int, bins) // no worker got any item
+	}
+
+	return counts, nil
+}
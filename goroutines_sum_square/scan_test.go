@@ -0,0 +1,150 @@
+package goroutines_sum_square
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func sequentialInclusiveScan(items []int) []int {
+	out := make([]int, len(items))
+	total := 0
+	for i, item := range items {
+		total += item
+		out[i] = total
+	}
+	return out
+}
+
+func sequentialExclusiveScan(items []int) []int {
+	out := make([]int, len(items))
+	total := 0
+	for i, item := range items {
+		out[i] = total
+		total += item
+	}
+	return out
+}
+
+func sequentialHistogram(items []int, bins, min, max int) []int {
+	counts := make([]int, bins)
+	width := float64(max-min) / float64(bins)
+	for _, item := range items {
+		if item < min || item > max {
+			continue
+		}
+		bin := int(float64(item-min) / width)
+		if bin == bins {
+			bin--
+		}
+		counts[bin]++
+	}
+	return counts
+}
+
+func TestScan(t *testing.T) {
+	if actual := must(InclusiveScan([]int{1, 2, 3, 4})); !reflect.DeepEqual(actual, []int{1, 3, 6, 10}) {
+		t.Errorf("inclusive scan %v expected [1 3 6 10]", actual)
+	}
+	if actual := must(ExclusiveScan([]int{1, 2, 3, 4})); !reflect.DeepEqual(actual, []int{0, 1, 3, 6}) {
+		t.Errorf("exclusive scan %v expected [0 1 3 6]", actual)
+	}
+	if actual := must(InclusiveScan([]float64{0.5, 0.25})); !reflect.DeepEqual(actual, []float64{0.5, 0.75}) {
+		t.Errorf("float scan %v expected [0.5 0.75]", actual)
+	}
+
+	for _, size := range []int{0, 1, 999, 1000, 1001, 54321} {
+		items := RandomArray(size+1, -1000, 1000)[:size]
+		inclusive, exclusive := sequentialInclusiveScan(items), sequentialExclusiveScan(items)
+
+		for idx, opts := range vectorConfigurations {
+			t.Run(fmt.Sprintf("%d/configuration %d", size, idx), func(t *testing.T) {
+				opts := append(opts, WithChunkSize(100))
+				if actual := must(InclusiveScan(items, opts...)); !reflect.DeepEqual(actual, inclusive) {
+					t.Errorf("inclusive scan differs from the sequential one")
+				}
+				if actual := must(ExclusiveScan(items, opts...)); !reflect.DeepEqual(actual, exclusive) {
+					t.Errorf("exclusive scan differs from the sequential one")
+				}
+			})
+		}
+	}
+}
+
+func TestScanErrors(t *testing.T) {
+	closed := NewPool(2)
+	closed.Close()
+
+	items := RandomArray(100000, 0, 1000)
+	for _, opts := range [][]Option{{WithPool(closed)}, {WithStrategy(Strategy(42))}, {WithStrategy(Strategy(42)), WithThreshold(1 << 30)}} {
+		if out, err := InclusiveScan(items, opts...); err == nil || out != nil {
+			t.Errorf("inclusive scan: expected an error, got %v", err)
+		}
+		if out, err := ExclusiveScan(items, opts...); err == nil || out != nil {
+			t.Errorf("exclusive scan: expected an error, got %v", err)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	actual, err := Histogram([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, -1, 11}, 5, 0, 10)
+	if err != nil || !reflect.DeepEqual(actual, []int{2, 2, 2, 2, 3}) {
+		t.Errorf("histogram %v %v expected [2 2 2 2 3]", actual, err)
+	}
+
+	items := RandomArray(123457, -100, 1100)
+	expected := sequentialHistogram(items, 7, 0, 1000)
+	for idx, opts := range vectorConfigurations {
+		t.Run(fmt.Sprintf("configuration %d", idx), func(t *testing.T) {
+			actual, err := Histogram(items, 7, 0, 1000, opts...)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("actual %v expected %v", actual, expected)
+			}
+		})
+	}
+
+	nan := math.NaN()
+	if actual, err := Histogram([]float64{nan, 0.1, nan, 0.9, math.Inf(1)}, 2, 0, 1, WithThreshold(0)); err != nil || !reflect.DeepEqual(actual, []int{1, 1}) {
+		t.Errorf("histogram with NaN %v %v expected [1 1]", actual, err)
+	}
+	if actual, err := Histogram([]float64{}, 3, 0, 1); err != nil || !reflect.DeepEqual(actual, []int{0, 0, 0}) {
+		t.Errorf("empty histogram %v %v expected [0 0 0]", actual, err)
+	}
+	if _, err := Histogram([]int{1}, 0, 0, 10); !errors.Is(err, ErrInvalidHistogram) {
+		t.Errorf("expected %v got %v", ErrInvalidHistogram, err)
+	}
+	if _, err := Histogram([]int{1}, 3, 10, 10); !errors.Is(err, ErrInvalidHistogram) {
+		t.Errorf("expected %v got %v", ErrInvalidHistogram, err)
+	}
+}
+
+func BenchmarkInclusiveScan(b *testing.B) {
+	for _, size := range []int{10000, 1000000} {
+		items := RandomArray(size, 0, 1000)
+		for _, strategy := range []Strategy{Sequential, PerCPU, Dynamic} {
+			b.Run(fmt.Sprintf("%v/%d", strategy, size), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_, _ = InclusiveScan(items, WithStrategy(strategy), WithThreshold(0))
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkHistogram(b *testing.B) {
+	for _, size := range []int{10000, 1000000} {
+		items := RandomArray(size, 0, 1000)
+		for _, strategy := range []Strategy{Sequential, PerCPU, Dynamic} {
+			b.Run(fmt.Sprintf("%v/%d", strategy, size), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_, _ = Histogram(items, 64, 0, 1000, WithStrategy(strategy), WithThreshold(0))
+				}
+			})
+		}
+	}
+}
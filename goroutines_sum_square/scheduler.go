@@ -0,0 +1,214 @@
+package goroutines_sum_square
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// scheduler hands out [start, end) ranges of the input to the workers of a reduction
+type scheduler interface {
+	// next returns the next range the worker should process, ok is false once there is nothing left for it
+	next(worker int) (start, end int, ok bool)
+}
+
+// schedule creates a fresh scheduler for n items shared between workers.
+// It is the pluggable strategy of reduceScheduled.
+type schedule func(n, workers int) scheduler
+
+// staticSchedule splits the input into one equal block per worker, like parallelSumSquare does.
+// It is the cheapest strategy but the slowest worker decides the total time.
+func staticSchedule() schedule {
+	return func(n, workers int) scheduler {
+		return &staticScheduler{
+			n:         n,
+			chunkSize: (n + workers - 1) / workers,
+			done:      make([]bool, workers),
+		}
+	}
+}
+
+type staticScheduler struct {
+	n         int
+	chunkSize int
+	done      []bool // done[w] is only accessed by worker w
+}
+
+func (s *staticScheduler) next(worker int) (int, int, bool) {
+	if s.done[worker] {
+		return 0, 0, false
+	}
+	s.done[worker] = true
+
+	start := worker * s.chunkSize
+	end := start + s.chunkSize
+	if end > s.n {
+		end = s.n
+	}
+	if start >= end {
+		return 0, 0, false
+	}
+
+	return start, end, true
+}
+
+// dynamicSchedule hands out fixed size chunks from a shared atomic cursor (OpenMP "dynamic").
+// Fast workers simply come back for more chunks.
+func dynamicSchedule(chunkSize int) schedule {
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+
+	return func(n, _ int) scheduler {
+		return &dynamicScheduler{n: int64(n), chunkSize: int64(chunkSize)}
+	}
+}
+
+type dynamicScheduler struct {
+	cursor    atomic.Int64
+	n         int64
+	chunkSize int64
+}
+
+func (s *dynamicScheduler) next(int) (int, int, bool) {
+	end := s.cursor.Add(s.chunkSize) // reserve the next chunk
+	start := end - s.chunkSize
+	if start >= s.n {
+		return 0, 0, false
+	}
+	if end > s.n {
+		end = s.n
+	}
+
+	return int(start), int(end), true
+}
+
+// guidedSchedule hands out chunks proportional to the remaining work divided by the number of workers,
+// but never smaller than minChunk (OpenMP "guided").
+// Chunks are big at the beginning and shrink at the end, so there are few cursor updates and a good balance.
+func guidedSchedule(minChunk int) schedule {
+	if minChunk < 1 {
+		minChunk = 1
+	}
+
+	return func(n, workers int) scheduler {
+		return &guidedScheduler{n: int64(n), workers: int64(workers), minChunk: int64(minChunk)}
+	}
+}
+
+type guidedScheduler struct {
+	cursor   atomic.Int64
+	n        int64
+	workers  int64
+	minChunk int64
+}
+
+func (s *guidedScheduler) next(int) (int, int, bool) {
+	for {
+		start := s.cursor.Load()
+		if start >= s.n {
+			return 0, 0, false
+		}
+
+		size := (s.n - start) / s.workers
+		if size < s.minChunk {
+			size = s.minChunk
+		}
+		end := start + size
+		if end > s.n {
+			end = s.n
+		}
+
+		if s.cursor.CompareAndSwap(start, end) { // another worker may have moved the cursor, retry
+			return int(start), int(end), true
+		}
+	}
+}
+
+// stealingSchedule splits the input into chunks and gives each worker its own deque of contiguous chunks.
+// A worker pops chunks from the bottom of its deque and steals from the top of the others once it is empty.
+func stealingSchedule(chunkSize int) schedule {
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+
+	return func(n, workers int) scheduler {
+		totalChunks := (n + chunkSize - 1) / chunkSize
+		deques := make([]*chunkDeque, workers) // one deque per worker
+		for w := 0; w < workers; w++ {
+			deques[w] = &chunkDeque{
+				low:  w * totalChunks / workers,
+				high: (w + 1) * totalChunks / workers,
+			}
+		}
+
+		return &stealingScheduler{n: n, chunkSize: chunkSize, deques: deques}
+	}
+}
+
+type stealingScheduler struct {
+	n         int
+	chunkSize int
+	deques    []*chunkDeque
+}
+
+// chunkDeque holds the chunk indices [low, high) that belong to a worker.
+// Both ends are only ever popped, so the content stays a contiguous range.
+type chunkDeque struct {
+	mu   sync.Mutex
+	low  int
+	high int
+}
+
+// popBottom is used by the owner of the deque
+func (d *chunkDeque) popBottom() (int, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.low >= d.high {
+		return 0, false
+	}
+	d.high--
+	return d.high, true
+}
+
+// steal is used by the other workers
+func (d *chunkDeque) steal() (int, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.low >= d.high {
+		return 0, false
+	}
+	d.low++
+	return d.low - 1, true
+}
+
+func (s *stealingScheduler) next(worker int) (int, int, bool) {
+	chunk, ok := s.deques[worker].popBottom()
+	for victim := 1; !ok && victim < len(s.deques); victim++ {
+		chunk, ok = s.deques[(worker+victim)%len(s.deques)].steal()
+	}
+	if !ok {
+		return 0, 0, false
+	}
+
+	start := chunk * s.chunkSize
+	end := start + s.chunkSize
+	if end > s.n {
+		end = s.n
+	}
+
+	return start, end, true
+}
+
+// reduceScheduled runs body over [0, n) with the given number of workers and sums the partial results.
+// The ranges are handed out by the scheduler created with sched, and the context is checked between two ranges.
+func reduceScheduled(ctx context.Context, n, workers int, sched schedule, body func(start, end int) int) (int, error) {
+	return reduceWith(ctx, n, workers, sched, nil, body, func(a, b int) int { return a + b })
+}
+
+// scheduledSumSquare computes the sum of squares with a pluggable scheduling strategy
+func scheduledSumSquare(ctx context.Context, items []int, workers int, sched schedule) (int, error) {
+	return reduceScheduled(ctx, len(items), workers, sched, func(start, end int) int {
+		return sumSquareKernel.fn(items[start:end])
+	})
+}
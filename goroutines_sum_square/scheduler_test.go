@@ -0,0 +1,125 @@
+package goroutines_sum_square
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+)
+
+var schedules = []struct {
+	name  string
+	sched schedule
+}{
+	{name: "static", sched: staticSchedule()},
+	{name: "dynamic", sched: dynamicSchedule(1000)},
+	{name: "guided", sched: guidedSchedule(100)},
+	{name: "stealing", sched: stealingSchedule(1000)},
+}
+
+func TestSchedulersCoverEveryItemOnce(t *testing.T) {
+	for _, s := range schedules {
+		for _, n := range []int{0, 1, 7, 999, 1000, 1001, 123457} {
+			for _, workers := range []int{1, 3, 16} {
+				t.Run(fmt.Sprintf("%s/%d/%d", s.name, n, workers), func(t *testing.T) {
+					visits := make([]int32, n)
+					_, err := reduceScheduled(context.Background(), n, workers, s.sched, func(start, end int) int {
+						for i := start; i < end; i++ {
+							atomic.AddInt32(&visits[i], 1)
+						}
+						return 0
+					})
+					if err != nil {
+						t.Fatalf("unexpected error %v", err)
+					}
+					for i, v := range visits {
+						if v != 1 {
+							t.Fatalf("item %d visited %d times", i, v)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestScheduledSumSquare(t *testing.T) {
+	items := RandomArray(123457, 0, 1000)
+	expected := simpleSumSquare(items)
+
+	for _, s := range schedules {
+		t.Run(s.name, func(t *testing.T) {
+			testFramework(t, func(items []int) int {
+				total, _ := scheduledSumSquare(context.Background(), items, 4, s.sched)
+				return total
+			})
+
+			actual, err := scheduledSumSquare(context.Background(), items, runtime.NumCPU(), s.sched)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if actual != expected {
+				t.Errorf("actual %v expected %v", actual, expected)
+			}
+		})
+	}
+}
+
+func TestScheduledSumSquareCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, s := range schedules {
+		t.Run(s.name, func(t *testing.T) {
+			if _, err := scheduledSumSquare(ctx, RandomArray(100000, 0, 1000), 4, s.sched); !errors.Is(err, context.Canceled) {
+				t.Errorf("expected %v got %v", context.Canceled, err)
+			}
+		})
+	}
+}
+
+// skewedSumSquare computes the same sum as simpleSumSquare but the last tenth of the input costs 100 times more,
+// as happens when the cost of an element depends on its value or position.
+func skewedSumSquare(items []int, start, end int) int {
+	total := 0
+	for i := start; i < end; i++ {
+		cost := 1
+		if i >= len(items)*9/10 {
+			cost = 100
+		}
+
+		square := 0
+		for r := 0; r < cost; r++ {
+			square += items[i] * items[i]
+		}
+		total += square / cost
+	}
+	return total
+}
+
+func BenchmarkSkewedWorkload(b *testing.B) {
+	items := RandomArray(1000000, 0, 1000)
+	b.ResetTimer()
+	for _, s := range schedules {
+		b.Run(s.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = reduceScheduled(context.Background(), len(items), runtime.NumCPU(), s.sched, func(start, end int) int {
+					return skewedSumSquare(items, start, end)
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkScheduledSumSquare(b *testing.B) {
+	for _, s := range schedules {
+		b.Run(s.name, func(b *testing.B) {
+			benchmarkFramework(b, func(items []int) int {
+				total, _ := scheduledSumSquare(context.Background(), items, runtime.NumCPU(), s.sched)
+				return total
+			})
+		})
+	}
+}
@@ -1,8 +1,8 @@
 package goroutines_sum_square
 
 import (
+	"context"
 	"math/rand"
-	"reflect"
 	"runtime"
 	"sync"
 	"unsafe"
@@ -97,33 +97,12 @@ func simpleSumSquare(items []int) int {
 	return total // return the total sum
 }
 
+// sumSquare sends every item through a pipeline: a generator, a squaring stage and a summing sink.
+// It used to spawn one goroutine per item and ping-pong each number over two unbuffered channels,
+// which serialized everything. The pipeline package now owns the channels and goroutines.
 func sumSquare(items []int) int {
-	number := make(chan int)   // channel for sending numbers
-	response := make(chan int) // channel for receiving responses
-
-	var wg sync.WaitGroup // wait group for waiting for all goroutines to finish
-
-	total := 0 // total sum
-
-	// Create a goroutine for each item in the slice
-	for _, item := range items {
-		wg.Add(1)           // increment the wait group counter
-		go func(item int) { // create a goroutine
-			defer wg.Done()    // decrement the wait group counter when the goroutine finishes
-			sum1 := <-number   // receive a number from the number channel
-			sum1 = sum1 * sum1 // square the number
-			response <- sum1   // send the result to the response channel
-		}(item) // pass the item to the goroutine
-		number <- item      // send the item to the number channel
-		total += <-response // receive the result from the response channel
-	}
-
-	defer close(number)   // close the number channel
-	defer close(response) // close the response channel
-
-	wg.Wait() // wait for all goroutines to finish
-
-	return total // return the total sum
+	total, _ := sumSquareContext(context.Background(), items) // a background context is never cancelled
+	return total
 }
 
 func parallelSumSquare(items []int) int {
@@ -169,12 +148,11 @@ func parallelSumSquare(items []int) int {
 }
 
 func unsafeSlice(items []int, start, end int) []int {
-	header := *(*reflect.SliceHeader)(unsafe.Pointer(&items))
-	header.Data = uintptr(unsafe.Pointer(&items[0]))
-	header.Len = end - start
-	header.Cap = end - start
+	if start >= end {
+		return nil // empty chunk, &items[start] may be out of range
+	}
 
-	return *(*[]int)(unsafe.Pointer(&header))
+	return unsafe.Slice(&items[start], end-start) // build the sub slice without bounds checks
 }
 
 func unsafeParallelSumSquare(items []int) int {
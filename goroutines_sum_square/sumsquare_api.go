@@ -0,0 +1,207 @@
+// Package goroutines_sum_square compares several ways of computing the sum of squares of a slice with goroutines.
+//
+// SumSquare and SumSquareContext expose every implementation behind functional options:
+//
+//	total := goroutines_sum_square.SumSquare(items,
+//		goroutines_sum_square.WithStrategy(goroutines_sum_square.Dynamic),
+//		goroutines_sum_square.WithChunkSize(4096),
+//	)
+//
+// Dot, the norms, Stats and CosineSimilarity run on the same chunking engine for any Number slice.
+package goroutines_sum_square
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// ErrUnknownStrategy is returned for a Strategy which is not one of the constants below
+var ErrUnknownStrategy = errors.New("goroutines_sum_square: unknown strategy")
+
+// Strategy selects the implementation used by SumSquare
+type Strategy int
+
+const (
+	// Sequential sums the items in a simple loop, without goroutines
+	Sequential Strategy = iota
+	// Chunked splits the items into chunks of the chunk size and starts one goroutine per chunk
+	Chunked
+	// PerCPU splits the items into one block per worker, this is the default strategy
+	PerCPU
+	// Unsafe is PerCPU with sub slices built through the unsafe package
+	Unsafe
+	// Dynamic lets the workers take chunks of the chunk size from a shared cursor
+	Dynamic
+	// Guided lets the workers take shrinking chunks, never smaller than the chunk size, from a shared cursor
+	Guided
+	// Stealing gives each worker a deque of chunks and lets idle workers steal from the others
+	Stealing
+)
+
+// valid reports whether s is one of the strategies above
+func (s Strategy) valid() bool {
+	return s >= Sequential && s <= Stealing
+}
+
+func (s Strategy) String() string {
+	switch s {
+	case Sequential:
+		return "sequential"
+	case Chunked:
+		return "chunked"
+	case PerCPU:
+		return "per-cpu"
+	case Unsafe:
+		return "unsafe"
+	case Dynamic:
+		return "dynamic"
+	case Guided:
+		return "guided"
+	case Stealing:
+		return "stealing"
+	default:
+		return fmt.Sprintf("Strategy(%d)", int(s))
+	}
+}
+
+// config holds the settings shared by the reducers of this package
+type config struct {
+	strategy  Strategy
+	chunkSize int
+	workers   int
+	threshold int
+
+	aggregation Aggregation
+	blockSize   int
+	pool        *Pool
+}
+
+// Option configures SumSquare and SumSquareContext
+type Option func(*config)
+
+// WithStrategy selects the implementation, PerCPU by default
+func WithStrategy(strategy Strategy) Option {
+	return func(c *config) {
+		c.strategy = strategy
+	}
+}
+
+// WithChunkSize sets the number of items handled by a chunk, 10000 by default.
+// It is also the number of items processed between two cancellation checks.
+func WithChunkSize(chunkSize int) Option {
+	return func(c *config) {
+		if chunkSize > 0 {
+			c.chunkSize = chunkSize
+		}
+	}
+}
+
+// WithWorkers sets the number of goroutines, runtime.NumCPU() by default.
+// Chunked ignores it as it starts one goroutine per chunk.
+func WithWorkers(workers int) Option {
+	return func(c *config) {
+		if workers > 0 {
+			c.workers = workers
+		}
+	}
+}
+
+// WithThreshold sets the input size up to which the items are summed sequentially, 10000 by default
+func WithThreshold(threshold int) Option {
+	return func(c *config) {
+		if threshold >= 0 {
+			c.threshold = threshold
+		}
+	}
+}
+
+// WithBlockSize sets the size of the square tiles used by the matrix products, 64 by default
+func WithBlockSize(blockSize int) Option {
+	return func(c *config) {
+		if blockSize > 0 {
+			c.blockSize = blockSize
+		}
+	}
+}
+
+func newConfig(opts ...Option) config {
+	cfg := config{
+		strategy:  PerCPU,
+		chunkSize: 10000,
+		workers:   runtime.NumCPU(),
+		threshold: 10000,
+		blockSize: 64,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// SumSquare returns the sum of the squares of items.
+// It panics on any error SumSquareContext would return: an unknown strategy, ErrAggregationStrategy,
+// or an error of the pool such as ErrPoolClosed or a PanicError.
+func SumSquare(items []int, opts ...Option) int {
+	total, err := SumSquareContext(context.Background(), items, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return total
+}
+
+// SumSquareContext returns the sum of the squares of items.
+// The computation stops between two chunks once ctx is done and ctx.Err() is returned.
+func SumSquareContext(ctx context.Context, items []int, opts ...Option) (int, error) {
+	cfg := newConfig(opts...)
+
+	if !cfg.strategy.valid() {
+		return 0, fmt.Errorf("%w %v", ErrUnknownStrategy, cfg.strategy)
+	}
+	if cfg.aggregation != ChannelAggregation && (cfg.strategy != PerCPU || cfg.pool != nil) {
+		return 0, fmt.Errorf("%w: %v with the %v strategy", ErrAggregationStrategy, cfg.aggregation, cfg.strategy)
+	}
+
+	if cfg.pool != nil && cfg.strategy != Sequential {
+		return reduce(ctx, len(items), cfg, func(start, end int) int {
+			return sumSquareKernel.fn(items[start:end])
+		}, add[int])
+	}
+
+	switch cfg.strategy {
+	case Sequential:
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		return sumSquareKernel.fn(items), nil
+	case Chunked:
+		return optimizedParallelSumSquareContext(ctx, items, cfg)
+	case PerCPU:
+		if cfg.aggregation != ChannelAggregation {
+			return aggregatedSumSquareContext(ctx, items, cfg)
+		}
+		return parallelSumSquareContext(ctx, items, cfg)
+	case Unsafe:
+		return unsafeParallelSumSquareContext(ctx, items, cfg)
+	case Dynamic:
+		return scheduledSumSquareContext(ctx, items, cfg, dynamicSchedule(cfg.chunkSize))
+	case Guided:
+		return scheduledSumSquareContext(ctx, items, cfg, guidedSchedule(cfg.chunkSize))
+	default: // Stealing
+		return scheduledSumSquareContext(ctx, items, cfg, stealingSchedule(cfg.chunkSize))
+	}
+}
+
+// scheduledSumSquareContext applies the threshold before handing the items to scheduledSumSquare
+func scheduledSumSquareContext(ctx context.Context, items []int, cfg config, sched schedule) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(items) <= cfg.threshold {
+		return sumSquareKernel.fn(items), nil
+	}
+
+	return scheduledSumSquare(ctx, items, cfg.workers, sched)
+}
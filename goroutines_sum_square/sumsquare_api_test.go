@@ -0,0 +1,108 @@
+package goroutines_sum_square_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/corentings/goTeaching/goroutines_sum_square"
+)
+
+var strategies = []goroutines_sum_square.Strategy{
+	goroutines_sum_square.Sequential,
+	goroutines_sum_square.Chunked,
+	goroutines_sum_square.PerCPU,
+	goroutines_sum_square.Unsafe,
+	goroutines_sum_square.Dynamic,
+	goroutines_sum_square.Guided,
+	goroutines_sum_square.Stealing,
+}
+
+func expectedSumSquare(items []int) int {
+	total := 0
+	for _, item := range items {
+		total += item * item
+	}
+	return total
+}
+
+func TestSumSquare(t *testing.T) {
+	inputs := [][]int{
+		{},
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		goroutines_sum_square.RandomArray(10000, 0, 1000),
+		goroutines_sum_square.RandomArray(10001, 0, 1000),
+		goroutines_sum_square.RandomArray(123457, -1000, 1000),
+	}
+
+	for _, strategy := range strategies {
+		for _, items := range inputs {
+			t.Run(fmt.Sprintf("%v/%d", strategy, len(items)), func(t *testing.T) {
+				expected := expectedSumSquare(items)
+				configurations := [][]goroutines_sum_square.Option{
+					{goroutines_sum_square.WithStrategy(strategy)},
+					{
+						goroutines_sum_square.WithStrategy(strategy),
+						goroutines_sum_square.WithThreshold(0),
+						goroutines_sum_square.WithChunkSize(777),
+						goroutines_sum_square.WithWorkers(3),
+					},
+				}
+				for _, opts := range configurations {
+					if actual := goroutines_sum_square.SumSquare(items, opts...); actual != expected {
+						t.Errorf("actual %v expected %v", actual, expected)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSumSquareContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	items := goroutines_sum_square.RandomArray(100000, 0, 1000)
+	for _, strategy := range strategies {
+		t.Run(strategy.String(), func(t *testing.T) {
+			_, err := goroutines_sum_square.SumSquareContext(ctx, items, goroutines_sum_square.WithStrategy(strategy))
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected %v got %v", context.Canceled, err)
+			}
+		})
+	}
+}
+
+func TestSumSquareUnknownStrategy(t *testing.T) {
+	pool := goroutines_sum_square.NewPool(2)
+	defer pool.Close()
+
+	for _, opts := range [][]goroutines_sum_square.Option{
+		{goroutines_sum_square.WithStrategy(goroutines_sum_square.Strategy(42))},
+		{goroutines_sum_square.WithStrategy(goroutines_sum_square.Strategy(-1))},
+		{goroutines_sum_square.WithStrategy(goroutines_sum_square.Strategy(42)), goroutines_sum_square.WithPool(pool)},
+	} {
+		_, err := goroutines_sum_square.SumSquareContext(context.Background(), []int{1, 2, 3}, opts...)
+		if !errors.Is(err, goroutines_sum_square.ErrUnknownStrategy) {
+			t.Errorf("expected %v got %v", goroutines_sum_square.ErrUnknownStrategy, err)
+		}
+	}
+}
+
+func BenchmarkSumSquare(b *testing.B) {
+	sizes := [][]int{goroutines_sum_square.RandomArray(100, 0, 100),
+		goroutines_sum_square.RandomArray(10000, 0, 10000),
+		goroutines_sum_square.RandomArray(1000000, 0, 1000000),
+	}
+	b.ResetTimer()
+	for _, strategy := range strategies {
+		for _, size := range sizes {
+			b.Run(fmt.Sprintf("%v/%d", strategy, len(size)), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					goroutines_sum_square.SumSquare(size, goroutines_sum_square.WithStrategy(strategy))
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package goroutines_sum_square
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/corentings/goTeaching/pipeline"
+)
+
+// simpleParallelSumSquareContext is simpleParallelSumSquare with cancellation support.
+// Each chunk checks the context before running, and the function returns ctx.Err() once cancelled.
+func simpleParallelSumSquareContext(ctx context.Context, items []int, cfg config) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(items) <= cfg.threshold { // Threshold for small slices
+		return simpleSumSquare(items), nil
+	}
+
+	chunkSize := cfg.chunkSize
+
+	// Divide the items into chunks
+	chunks := make([][]int, 0)
+	for i := 0; i < len(items); i += chunkSize {
+		end := i + chunkSize
+		if end > len(items) {
+			end = len(items)
+		}
+		chunks = append(chunks, items[i:end])
+	}
+
+	wg := sync.WaitGroup{}
+	resultChan := make(chan int, len(chunks)) // buffered so that no goroutine blocks on send
+
+	for _, chunk := range chunks {
+		if ctx.Err() != nil {
+			break // stop spawning goroutines once cancelled
+		}
+
+		wg.Add(1)
+		go func(chunk []int) {
+			defer wg.Done()
+			if ctx.Err() != nil {
+				return // skip the chunk if the context is cancelled
+			}
+			resultChan <- simpleSumSquare(chunk)
+		}(chunk)
+	}
+
+	wg.Wait() // every goroutine has returned, nothing is leaked
+	close(resultChan)
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for partialSum := range resultChan {
+		total += partialSum
+	}
+
+	return total, nil
+}
+
+// optimizedParallelSumSquareContext is optimizedParallelSumSquare with cancellation support.
+func optimizedParallelSumSquareContext(ctx context.Context, items []int, cfg config) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(items) <= cfg.threshold {
+		return simpleSumSquare(items), nil
+	}
+
+	chunkSize := cfg.chunkSize
+
+	chunkIndices := make([]struct{ start, end int }, 0)
+	for i := 0; i < len(items); i += chunkSize {
+		end := i + chunkSize
+		if end > len(items) {
+			end = len(items)
+		}
+		chunkIndices = append(chunkIndices, struct{ start, end int }{i, end})
+	}
+
+	wg := sync.WaitGroup{}
+	resultChan := make(chan int, len(chunkIndices))
+
+	for _, indices := range chunkIndices {
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			if ctx.Err() != nil {
+				return
+			}
+			resultChan <- simpleSumSquare(items[start:end])
+		}(indices.start, indices.end)
+	}
+
+	wg.Wait()
+	close(resultChan)
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for partialSum := range resultChan {
+		total += partialSum
+	}
+
+	return total, nil
+}
+
+// parallelSumSquareContext is parallelSumSquare with cancellation support.
+// Each worker checks the context every cfg.chunkSize items.
+func parallelSumSquareContext(ctx context.Context, items []int, cfg config) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(items) <= cfg.threshold { // Threshold for small slices
+		return simpleSumSquare(items), nil
+	}
+
+	totalCPU := cfg.workers
+	chunkSize := (len(items) + totalCPU - 1) / totalCPU
+	resultChan := make(chan int, totalCPU)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < totalCPU; i++ {
+		start := i * chunkSize
+		end := (i + 1) * chunkSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			total := 0
+			for chunkStart := start; chunkStart < end; chunkStart += cfg.chunkSize {
+				if ctx.Err() != nil {
+					return // give up on the remaining items
+				}
+
+				chunkEnd := chunkStart + cfg.chunkSize
+				if chunkEnd > end {
+					chunkEnd = end
+				}
+				for i := chunkStart; i < chunkEnd; i++ {
+					item := items[i]
+					total += item * item
+				}
+			}
+			resultChan <- total
+		}(start, end)
+	}
+
+	wg.Wait()
+	close(resultChan)
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for partialSum := range resultChan {
+		total += partialSum
+	}
+
+	return total, nil
+}
+
+// unsafeParallelSumSquareContext is unsafeParallelSumSquare with cancellation support.
+func unsafeParallelSumSquareContext(ctx context.Context, items []int, cfg config) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(items) <= cfg.threshold { // Threshold for small slices
+		return simpleSumSquare(items), nil
+	}
+
+	totalCPU := cfg.workers
+	chunkSize := (len(items) + totalCPU - 1) / totalCPU
+	resultChan := make(chan int, totalCPU)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < totalCPU; i++ {
+		start := i * chunkSize
+		end := (i + 1) * chunkSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			partialItems := unsafeSlice(items, start, end)
+			partialSum := 0
+			for chunkStart := 0; chunkStart < len(partialItems); chunkStart += cfg.chunkSize {
+				if ctx.Err() != nil {
+					return
+				}
+
+				chunkEnd := chunkStart + cfg.chunkSize
+				if chunkEnd > len(partialItems) {
+					chunkEnd = len(partialItems)
+				}
+				for _, item := range partialItems[chunkStart:chunkEnd] {
+					partialSum += item * item
+				}
+			}
+			resultChan <- partialSum
+		}(start, end)
+	}
+
+	wg.Wait()
+	close(resultChan)
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for partialSum := range resultChan {
+		total += partialSum
+	}
+
+	return total, nil
+}
+
+// sumSquareContext is sumSquare with cancellation support, the pipeline stops as soon as ctx is done.
+func sumSquareContext(ctx context.Context, items []int) (int, error) {
+	p := pipeline.New(ctx)
+
+	numbers := pipeline.Generate(p, items)
+	squares := pipeline.Stage(p, numbers, runtime.NumCPU(), func(_ context.Context, item int) (int, error) {
+		return item * item, nil
+	})
+
+	total := 0
+	err := pipeline.Sink(p, squares, func(square int) error {
+		total += square
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
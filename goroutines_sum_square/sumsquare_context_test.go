@@ -0,0 +1,122 @@
+package goroutines_sum_square
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+type contextSumFunction func(context.Context, []int) (int, error)
+
+var contextSumFunctions = []struct {
+	name        string
+	sumFunction contextSumFunction
+}{
+	{name: "sumSquare", sumFunction: sumSquareContext},
+	{name: "simpleParallelSumSquare", sumFunction: withDefaultConfig(simpleParallelSumSquareContext)},
+	{name: "optimizedParallelSumSquare", sumFunction: withDefaultConfig(optimizedParallelSumSquareContext)},
+	{name: "parallelSumSquare", sumFunction: withDefaultConfig(parallelSumSquareContext)},
+	{name: "unsafeParallelSumSquare", sumFunction: withDefaultConfig(unsafeParallelSumSquareContext)},
+}
+
+func withDefaultConfig(sumFunction func(context.Context, []int, config) (int, error)) contextSumFunction {
+	return func(ctx context.Context, items []int) (int, error) {
+		return sumFunction(ctx, items, newConfig())
+	}
+}
+
+func withBackground(sumFunction contextSumFunction) func([]int) int {
+	return func(items []int) int {
+		total, err := sumFunction(context.Background(), items)
+		if err != nil {
+			panic(err)
+		}
+		return total
+	}
+}
+
+func TestContextSumSquare(t *testing.T) {
+	for _, function := range contextSumFunctions {
+		t.Run(function.name, func(t *testing.T) {
+			testFramework(t, withBackground(function.sumFunction))
+		})
+	}
+}
+
+func TestContextSumSquareLargeInput(t *testing.T) {
+	items := RandomArray(123457, 0, 1000)
+	expected := simpleSumSquare(items)
+
+	for _, function := range contextSumFunctions {
+		t.Run(function.name, func(t *testing.T) {
+			actual, err := function.sumFunction(context.Background(), items)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if actual != expected {
+				t.Errorf("actual %v expected %v", actual, expected)
+			}
+		})
+	}
+}
+
+func TestContextSumSquareCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	deadlineCtx, deadlineCancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer deadlineCancel()
+
+	for _, function := range contextSumFunctions {
+		t.Run(function.name, func(t *testing.T) {
+			for _, items := range [][]int{RandomArray(100, 0, 100), RandomArray(100000, 0, 1000)} {
+				if _, err := function.sumFunction(ctx, items); !errors.Is(err, context.Canceled) {
+					t.Errorf("expected %v got %v", context.Canceled, err)
+				}
+				if _, err := function.sumFunction(deadlineCtx, items); !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("expected %v got %v", context.DeadlineExceeded, err)
+				}
+			}
+		})
+	}
+}
+
+func TestContextSumSquareNoGoroutineLeak(t *testing.T) {
+	items := RandomArray(1000000, 0, 1000)
+	expected := simpleSumSquare(items)
+	before := runtime.NumGoroutine()
+
+	for _, function := range contextSumFunctions {
+		if function.name == "sumSquare" {
+			continue // a few channel operations per item, too slow for a million items
+		}
+		for i := 0; i < 20; i++ {
+			ctx, cancel := context.WithCancel(context.Background())
+			go func(delay time.Duration) {
+				time.Sleep(delay)
+				cancel()
+			}(time.Duration(i) * 10 * time.Microsecond)
+
+			actual, err := function.sumFunction(ctx, items)
+			if err == nil && actual != expected {
+				t.Errorf("%s: actual %v expected %v", function.name, actual, expected)
+			}
+			if err != nil && !errors.Is(err, context.Canceled) {
+				t.Errorf("%s: unexpected error %v", function.name, err)
+			}
+			cancel()
+		}
+	}
+
+	// Give the cancel goroutines started above some time to exit
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("goroutine leak: %d goroutines before, %d after", before, after)
+	}
+}
@@ -113,6 +113,26 @@ func TestUnsafeParallelSumSquare(t *testing.T) {
 	testFramework(t, unsafeParallelSumSquare)
 }
 
+// TestUnsafeSlice is a regression test: unsafeSlice used to start every chunk at items[0],
+// so unsafeParallelSumSquare summed the first chunk once per CPU
+func TestUnsafeSlice(t *testing.T) {
+	items := thousandFirstIntegers()
+	for _, bounds := range [][2]int{{0, 10}, {10, 20}, {500, 1000}, {999, 1000}, {1000, 1000}, {3, 3}} {
+		actual := unsafeSlice(items, bounds[0], bounds[1])
+		if expected := items[bounds[0]:bounds[1]]; len(actual) != len(expected) || (len(actual) > 0 && !reflect.DeepEqual(actual, expected)) {
+			t.Errorf("unsafeSlice(%d, %d) = %v expected %v", bounds[0], bounds[1], actual, expected)
+		}
+	}
+}
+
+// TestUnsafeParallelSumSquareChunks uses an input above the threshold, so that the chunks are actually built
+func TestUnsafeParallelSumSquareChunks(t *testing.T) {
+	items := RandomArray(100003, 0, 1000)
+	if actual, expected := unsafeParallelSumSquare(items), simpleSumSquare(items); actual != expected {
+		t.Errorf("actual %d expected %d", actual, expected)
+	}
+}
+
 func BenchmarkUnsafeParallelSumSquare(b *testing.B) {
 	benchmarkFramework(b, unsafeParallelSumSquare)
 }
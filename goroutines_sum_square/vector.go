@@ -0,0 +1,203 @@
+package goroutines_sum_square
+
+import (
+	"context"
+	"errors"
+	"math"
+)
+
+// ErrLengthMismatch is returned by the functions working on two vectors of different lengths
+var ErrLengthMismatch = errors.New("goroutines_sum_square: vectors have different lengths")
+
+// ErrZeroVector is returned by CosineSimilarity when one of the vectors has a zero norm
+var ErrZeroVector = errors.New("goroutines_sum_square: zero vector")
+
+// The functions below accept the same options as SumSquare and return the same errors.
+// Only the strategy, chunk size, worker count, threshold and pool are used, Chunked behaves like Dynamic
+// and Unsafe like PerCPU.
+
+// Dot returns the dot product of a and b
+func Dot[T Number](a, b []T, opts ...Option) (T, error) {
+	if len(a) != len(b) {
+		return 0, ErrLengthMismatch
+	}
+
+	return reduce(context.Background(), len(a), newConfig(opts...), func(start, end int) T {
+		var total T
+		for i := start; i < end; i++ {
+			total += a[i] * b[i]
+		}
+		return total
+	}, add[T])
+}
+
+// NormL1 returns the sum of the absolute values of items
+func NormL1[T Number](items []T, opts ...Option) (float64, error) {
+	total, err := reduce(context.Background(), len(items), newConfig(opts...), func(start, end int) float64 {
+		total := 0.0
+		for _, item := range items[start:end] {
+			total += math.Abs(float64(item))
+		}
+		return total
+	}, add[float64])
+	return total, err
+}
+
+// NormL2 returns the euclidean norm of items, the square root of its sum of squares
+func NormL2[T Number](items []T, opts ...Option) (float64, error) {
+	total, err := reduce(context.Background(), len(items), newConfig(opts...), func(start, end int) float64 {
+		total := 0.0
+		for _, item := range items[start:end] {
+			total += float64(item) * float64(item)
+		}
+		return total
+	}, add[float64])
+	return math.Sqrt(total), err
+}
+
+// NormInf returns the largest absolute value of items, 0 for an empty slice
+func NormInf[T Number](items []T, opts ...Option) (float64, error) {
+	total, err := reduce(context.Background(), len(items), newConfig(opts...), func(start, end int) float64 {
+		largest := 0.0
+		for _, item := range items[start:end] {
+			largest = math.Max(largest, math.Abs(float64(item)))
+		}
+		return largest
+	}, math.Max)
+	return total, err
+}
+
+// Mean returns the arithmetic mean of items, NaN for an empty slice
+func Mean[T Number](items []T, opts ...Option) (float64, error) {
+	moments, err := Stats(items, opts...)
+	return moments.Mean(), err
+}
+
+// Variance returns the population variance of items, NaN for an empty slice
+func Variance[T Number](items []T, opts ...Option) (float64, error) {
+	moments, err := Stats(items, opts...)
+	return moments.Variance(), err
+}
+
+// StdDev returns the population standard deviation of items, NaN for an empty slice
+func StdDev[T Number](items []T, opts ...Option) (float64, error) {
+	moments, err := Stats(items, opts...)
+	return moments.StdDev(), err
+}
+
+// Stats computes the Moments of items in a single parallel pass
+func Stats[T Number](items []T, opts ...Option) (Moments, error) {
+	return reduce(context.Background(), len(items), newConfig(opts...), func(start, end int) Moments {
+		var moments Moments
+		for _, item := range items[start:end] {
+			moments = moments.Add(float64(item))
+		}
+		return moments
+	}, Moments.Merge)
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b
+func CosineSimilarity[T Number](a, b []T, opts ...Option) (float64, error) {
+	if len(a) != len(b) {
+		return 0, ErrLengthMismatch
+	}
+
+	// dot product and both squared norms are computed in the same pass
+	type cosineSums struct{ dot, a, b float64 }
+
+	sums, err := reduce(context.Background(), len(a), newConfig(opts...), func(start, end int) cosineSums {
+		var sums cosineSums
+		for i := start; i < end; i++ {
+			x, y := float64(a[i]), float64(b[i])
+			sums.dot += x * y
+			sums.a += x * x
+			sums.b += y * y
+		}
+		return sums
+	}, func(x, y cosineSums) cosineSums {
+		return cosineSums{dot: x.dot + y.dot, a: x.a + y.a, b: x.b + y.b}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if sums.a == 0 || sums.b == 0 {
+		return 0, ErrZeroVector
+	}
+
+	return sums.dot / (math.Sqrt(sums.a) * math.Sqrt(sums.b)), nil
+}
+
+func add[T Number](a, b T) T {
+	return a + b
+}
+
+// Moments holds the count, mean and sum of squared deviations of a set of values (Welford's algorithm).
+// Moments computed on different parts of a slice can be merged, which is what makes them parallel friendly.
+// The zero value holds no value.
+type Moments struct {
+	count int
+	mean  float64
+	m2    float64 // sum of the squared deviations from the mean
+}
+
+// Add returns the moments with x added
+func (m Moments) Add(x float64) Moments {
+	m.count++
+	delta := x - m.mean
+	m.mean += delta / float64(m.count)
+	m.m2 += delta * (x - m.mean)
+	return m
+}
+
+// Merge returns the moments of the union of both sets of values (Chan et al.)
+func (m Moments) Merge(other Moments) Moments {
+	if m.count == 0 {
+		return other
+	}
+	if other.count == 0 {
+		return m
+	}
+
+	count := m.count + other.count
+	delta := other.mean - m.mean
+	return Moments{
+		count: count,
+		mean:  m.mean + delta*float64(other.count)/float64(count),
+		m2:    m.m2 + other.m2 + delta*delta*float64(m.count)*float64(other.count)/float64(count),
+	}
+}
+
+// Count returns the number of values
+func (m Moments) Count() int {
+	return m.count
+}
+
+// Mean returns the mean of the values, NaN if there is none
+func (m Moments) Mean() float64 {
+	if m.count == 0 {
+		return math.NaN()
+	}
+	return m.mean
+}
+
+// Variance returns the population variance of the values, NaN if there is none
+func (m Moments) Variance() float64 {
+	if m.count == 0 {
+		return math.NaN()
+	}
+	return m.m2 / float64(m.count)
+}
+
+// SampleVariance returns the unbiased sample variance of the values, NaN if there are less than two
+func (m Moments) SampleVariance() float64 {
+	if m.count < 2 {
+		return math.NaN()
+	}
+	return m.m2 / float64(m.count-1)
+}
+
+// StdDev returns the population standard deviation of the values, NaN if there is none
+func (m Moments) StdDev() float64 {
+	return math.Sqrt(m.Variance())
+}
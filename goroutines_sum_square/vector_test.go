@@ -0,0 +1,254 @@
+package goroutines_sum_square
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// vectorConfigurations run every reduction sequentially and through each schedule of the engine
+var vectorConfigurations = [][]Option{
+	{WithStrategy(Sequential)},
+	{},
+	{WithThreshold(0), WithWorkers(3), WithStrategy(PerCPU)},
+	{WithThreshold(0), WithWorkers(5), WithStrategy(Dynamic), WithChunkSize(97)},
+	{WithThreshold(0), WithWorkers(4), WithStrategy(Guided), WithChunkSize(10)},
+	{WithThreshold(0), WithWorkers(6), WithStrategy(Stealing), WithChunkSize(101)},
+}
+
+func randomFloats(size int) []float64 {
+	array := make([]float64, size)
+	for i := range array {
+		array[i] = rand.NormFloat64()*10 + 3
+	}
+	return array
+}
+
+func almostEqual(a, b float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+// must returns the value, the errors are checked by TestVectorErrors
+func must[T any](value T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+func TestDot(t *testing.T) {
+	a := RandomArray(12345, -1000, 1000)
+	b := RandomArray(12345, -1000, 1000)
+	expected := 0
+	for i := range a {
+		expected += a[i] * b[i]
+	}
+
+	for idx, opts := range vectorConfigurations {
+		t.Run(fmt.Sprintf("configuration %d", idx), func(t *testing.T) {
+			actual, err := Dot(a, b, opts...)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if actual != expected {
+				t.Errorf("actual %v expected %v", actual, expected)
+			}
+
+			if squares, _ := Dot(a, a, opts...); squares != simpleSumSquare(a) {
+				t.Errorf("dot product with itself %v expected %v", squares, simpleSumSquare(a))
+			}
+		})
+	}
+
+	if _, err := Dot([]int{1, 2}, []int{1}); !errors.Is(err, ErrLengthMismatch) {
+		t.Errorf("expected %v got %v", ErrLengthMismatch, err)
+	}
+}
+
+func TestNorms(t *testing.T) {
+	items := randomFloats(12345)
+	expectedL1, expectedL2, expectedInf := 0.0, 0.0, 0.0
+	for _, item := range items {
+		expectedL1 += math.Abs(item)
+		expectedL2 += item * item
+		expectedInf = math.Max(expectedInf, math.Abs(item))
+	}
+	expectedL2 = math.Sqrt(expectedL2)
+
+	for idx, opts := range vectorConfigurations {
+		t.Run(fmt.Sprintf("configuration %d", idx), func(t *testing.T) {
+			if actual := must(NormL1(items, opts...)); !almostEqual(actual, expectedL1) {
+				t.Errorf("L1 actual %v expected %v", actual, expectedL1)
+			}
+			if actual := must(NormL2(items, opts...)); !almostEqual(actual, expectedL2) {
+				t.Errorf("L2 actual %v expected %v", actual, expectedL2)
+			}
+			if actual := must(NormInf(items, opts...)); actual != expectedInf {
+				t.Errorf("Linf actual %v expected %v", actual, expectedInf)
+			}
+		})
+	}
+
+	if actual := must(NormL2([]int{3, -4})); actual != 5 {
+		t.Errorf("L2 of (3, -4) is %v expected 5", actual)
+	}
+	if actual := must(NormInf([]int8{3, -128, 5})); actual != 128 {
+		t.Errorf("Linf of (3, -128, 5) is %v expected 128", actual)
+	}
+}
+
+func TestStats(t *testing.T) {
+	items := randomFloats(12345)
+
+	// two-pass reference
+	mean := 0.0
+	for _, item := range items {
+		mean += item
+	}
+	mean /= float64(len(items))
+	variance := 0.0
+	for _, item := range items {
+		variance += (item - mean) * (item - mean)
+	}
+	variance /= float64(len(items))
+
+	for idx, opts := range vectorConfigurations {
+		t.Run(fmt.Sprintf("configuration %d", idx), func(t *testing.T) {
+			if actual := must(Mean(items, opts...)); !almostEqual(actual, mean) {
+				t.Errorf("mean actual %v expected %v", actual, mean)
+			}
+			if actual := must(Variance(items, opts...)); !almostEqual(actual, variance) {
+				t.Errorf("variance actual %v expected %v", actual, variance)
+			}
+			if actual := must(StdDev(items, opts...)); !almostEqual(actual, math.Sqrt(variance)) {
+				t.Errorf("standard deviation actual %v expected %v", actual, math.Sqrt(variance))
+			}
+			if actual := must(Stats(items, opts...)).Count(); actual != len(items) {
+				t.Errorf("count actual %v expected %v", actual, len(items))
+			}
+		})
+	}
+
+	moments := must(Stats([]int{2, 4, 4, 4, 5, 5, 7, 9}))
+	if moments.Mean() != 5 || moments.Variance() != 4 || moments.StdDev() != 2 || !almostEqual(moments.SampleVariance(), 32.0/7) {
+		t.Errorf("unexpected moments %+v", moments)
+	}
+
+	if empty := must(Stats([]int{})); !math.IsNaN(empty.Mean()) || !math.IsNaN(empty.Variance()) {
+		t.Errorf("expected NaN for an empty slice, got %+v", empty)
+	}
+}
+
+func TestMomentsMerge(t *testing.T) {
+	items := randomFloats(1000)
+
+	var whole, left, right Moments
+	for i, item := range items {
+		whole = whole.Add(item)
+		if i < 300 {
+			left = left.Add(item)
+		} else {
+			right = right.Add(item)
+		}
+	}
+
+	merged := left.Merge(right)
+	if merged.Count() != whole.Count() || !almostEqual(merged.Mean(), whole.Mean()) || !almostEqual(merged.Variance(), whole.Variance()) {
+		t.Errorf("merged %+v expected %+v", merged, whole)
+	}
+	if (Moments{}).Merge(whole) != whole || whole.Merge(Moments{}) != whole {
+		t.Errorf("the zero Moments should be the identity of Merge")
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	cosineTests := []struct {
+		a, b     []float64
+		expected float64
+		name     string
+	}{
+		{a: []float64{1, 0}, b: []float64{0, 1}, expected: 0, name: "orthogonal"},
+		{a: []float64{1, 2, 3}, b: []float64{2, 4, 6}, expected: 1, name: "collinear"},
+		{a: []float64{1, 2, 3}, b: []float64{-1, -2, -3}, expected: -1, name: "opposite"},
+		{a: []float64{1, 1}, b: []float64{1, 0}, expected: 1 / math.Sqrt2, name: "45 degrees"},
+	}
+
+	for _, test := range cosineTests {
+		for idx, opts := range vectorConfigurations {
+			t.Run(fmt.Sprintf("%s/configuration %d", test.name, idx), func(t *testing.T) {
+				actual, err := CosineSimilarity(test.a, test.b, opts...)
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				if !almostEqual(actual, test.expected) {
+					t.Errorf("actual %v expected %v", actual, test.expected)
+				}
+			})
+		}
+	}
+
+	if _, err := CosineSimilarity([]int{0, 0}, []int{1, 2}); !errors.Is(err, ErrZeroVector) {
+		t.Errorf("expected %v got %v", ErrZeroVector, err)
+	}
+	if _, err := CosineSimilarity([]int{1}, []int{1, 2}); !errors.Is(err, ErrLengthMismatch) {
+		t.Errorf("expected %v got %v", ErrLengthMismatch, err)
+	}
+}
+
+func TestVectorErrors(t *testing.T) {
+	closed := NewPool(2)
+	closed.Close()
+
+	items := randomFloats(100000)
+	functions := map[string]func(opts ...Option) error{
+		"dot":    func(opts ...Option) error { _, err := Dot(items, items, opts...); return err },
+		"L1":     func(opts ...Option) error { _, err := NormL1(items, opts...); return err },
+		"L2":     func(opts ...Option) error { _, err := NormL2(items, opts...); return err },
+		"Linf":   func(opts ...Option) error { _, err := NormInf(items, opts...); return err },
+		"stats":  func(opts ...Option) error { _, err := Stats(items, opts...); return err },
+		"mean":   func(opts ...Option) error { _, err := Mean(items, opts...); return err },
+		"cosine": func(opts ...Option) error { _, err := CosineSimilarity(items, items, opts...); return err },
+	}
+
+	for name, fn := range functions {
+		t.Run(name, func(t *testing.T) {
+			if err := fn(WithPool(closed)); !errors.Is(err, ErrPoolClosed) {
+				t.Errorf("closed pool: expected %v got %v", ErrPoolClosed, err)
+			}
+			if err := fn(WithStrategy(Strategy(42))); !errors.Is(err, ErrUnknownStrategy) {
+				t.Errorf("unknown strategy: expected %v got %v", ErrUnknownStrategy, err)
+			}
+		})
+	}
+}
+
+func BenchmarkDot(b *testing.B) {
+	for _, size := range []int{1000, 100000, 1000000} {
+		x, y := randomFloats(size), randomFloats(size)
+		for _, strategy := range []Strategy{Sequential, PerCPU, Dynamic} {
+			b.Run(fmt.Sprintf("%v/%d", strategy, size), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_, _ = Dot(x, y, WithStrategy(strategy))
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkStats(b *testing.B) {
+	for _, size := range []int{1000, 100000, 1000000} {
+		items := randomFloats(size)
+		for _, strategy := range []Strategy{Sequential, PerCPU, Dynamic} {
+			b.Run(fmt.Sprintf("%v/%d", strategy, size), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_, _ = Stats(items, WithStrategy(strategy))
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,351 @@
+// Package pipeline builds typed fan-out/fan-in pipelines out of channels.
+//
+// Every stage runs in its own goroutines and is attached to a Pipeline.
+// The first error returned by a stage cancels the whole pipeline, and Sink or Wait report it
+// once every goroutine has returned:
+//
+//	p := pipeline.New(ctx)
+//	squares := pipeline.Stage(p, pipeline.Generate(p, items), 4, square)
+//	err := pipeline.Sink(p, squares, func(v int) error { total += v; return nil })
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// Pipeline holds the context and the goroutines shared by the stages of a pipeline
+type Pipeline struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	once sync.Once
+	err  error
+}
+
+// New returns a Pipeline whose stages stop when ctx is done
+func New(ctx context.Context) *Pipeline {
+	inner, cancel := context.WithCancel(ctx)
+	return &Pipeline{parent: ctx, ctx: inner, cancel: cancel}
+}
+
+// Context returns the context of the pipeline, it is cancelled on the first error
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Fail records err if it is the first error of the pipeline and cancels every stage
+func (p *Pipeline) Fail(err error) {
+	p.once.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
+
+// Wait waits for every goroutine of the pipeline and returns the first error,
+// or the error of the parent context if it was cancelled.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	defer p.cancel()
+
+	if p.err != nil {
+		return p.err
+	}
+	return p.parent.Err()
+}
+
+// goroutine runs fn in a goroutine tracked by Wait
+func (p *Pipeline) goroutine(fn func()) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		fn()
+	}()
+}
+
+// send sends v on out unless the pipeline is cancelled first
+func send[T any](p *Pipeline, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-p.ctx.Done():
+		return false
+	}
+}
+
+// receive receives from in unless the pipeline is cancelled first, ok is false once in is closed or cancelled
+func receive[T any](p *Pipeline, in <-chan T) (T, bool) {
+	select {
+	case v, ok := <-in:
+		return v, ok
+	case <-p.ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
+
+// Generate emits the items one by one
+func Generate[T any](p *Pipeline, items []T) <-chan T {
+	out := make(chan T)
+	p.goroutine(func() {
+		defer close(out)
+		for _, item := range items {
+			if !send(p, out, item) {
+				return
+			}
+		}
+	})
+	return out
+}
+
+// StageOption configures a Stage
+type StageOption func(*stageConfig)
+
+type stageConfig struct {
+	ordered bool
+	buffer  int
+}
+
+// Ordered makes the stage emit its results in the order of its input.
+// At most twice as many items as workers are in flight, a slow item holds back the ones after it.
+func Ordered() StageOption {
+	return func(c *stageConfig) {
+		c.ordered = true
+	}
+}
+
+// Buffer sets the capacity of the output channel of the stage, 0 by default
+func Buffer(size int) StageOption {
+	return func(c *stageConfig) {
+		if size > 0 {
+			c.buffer = size
+		}
+	}
+}
+
+// Stage applies fn to every item of in with the given number of worker goroutines.
+// Results are emitted as soon as they are ready unless the Ordered option is given.
+// The first error returned by fn cancels the pipeline.
+func Stage[In, Out any](p *Pipeline, in <-chan In, workers int, fn func(context.Context, In) (Out, error), opts ...StageOption) <-chan Out {
+	cfg := stageConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	if cfg.ordered {
+		return orderedStage(p, in, workers, fn, cfg)
+	}
+
+	out := make(chan Out, cfg.buffer)
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		p.goroutine(func() {
+			defer wg.Done()
+			for {
+				item, ok := receive(p, in)
+				if !ok {
+					return
+				}
+				result, err := fn(p.ctx, item)
+				if err != nil {
+					p.Fail(err)
+					return
+				}
+				if !send(p, out, result) {
+					return
+				}
+			}
+		})
+	}
+
+	// close the output once every worker is done
+	p.goroutine(func() {
+		wg.Wait()
+		close(out)
+	})
+
+	return out
+}
+
+type indexed[T any] struct {
+	index int
+	value T
+}
+
+// orderedStage numbers the items, processes them with the workers and emits the results in order.
+// A window of tokens bounds the number of items between the numbering and the reordering.
+func orderedStage[In, Out any](p *Pipeline, in <-chan In, workers int, fn func(context.Context, In) (Out, error), cfg stageConfig) <-chan Out {
+	jobs := make(chan indexed[In])
+	results := make(chan indexed[Out])
+	window := make(chan struct{}, 2*workers)
+	out := make(chan Out, cfg.buffer)
+
+	// numbering
+	p.goroutine(func() {
+		defer close(jobs)
+		index := 0
+		for {
+			item, ok := receive(p, in)
+			if !ok {
+				return
+			}
+			if !send(p, window, struct{}{}) {
+				return
+			}
+			if !send(p, jobs, indexed[In]{index: index, value: item}) {
+				return
+			}
+			index++
+		}
+	})
+
+	// workers
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		p.goroutine(func() {
+			defer wg.Done()
+			for {
+				job, ok := receive(p, jobs)
+				if !ok {
+					return
+				}
+				result, err := fn(p.ctx, job.value)
+				if err != nil {
+					p.Fail(err)
+					return
+				}
+				if !send(p, results, indexed[Out]{index: job.index, value: result}) {
+					return
+				}
+			}
+		})
+	}
+	p.goroutine(func() {
+		wg.Wait()
+		close(results)
+	})
+
+	// reordering
+	p.goroutine(func() {
+		defer close(out)
+		pending := make(map[int]Out)
+		next := 0
+		for {
+			result, ok := receive(p, results)
+			if !ok {
+				return
+			}
+			pending[result.index] = result.value
+			for {
+				value, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				if !send(p, out, value) {
+					return
+				}
+				<-window // the item left the stage, let the next one in
+				next++
+			}
+		}
+	})
+
+	return out
+}
+
+// FanOut distributes the items of in between n channels, each item goes to the first reader available
+func FanOut[T any](p *Pipeline, in <-chan T, n int) []<-chan T {
+	outs := make([]<-chan T, n)
+	for i := 0; i < n; i++ {
+		out := make(chan T)
+		outs[i] = out
+		p.goroutine(func() {
+			defer close(out)
+			for {
+				item, ok := receive(p, in)
+				if !ok || !send(p, out, item) {
+					return
+				}
+			}
+		})
+	}
+	return outs
+}
+
+// FanIn merges the items of every input channel into one channel, in no particular order
+func FanIn[T any](p *Pipeline, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+	wg := sync.WaitGroup{}
+	wg.Add(len(ins))
+	for _, in := range ins {
+		in := in
+		p.goroutine(func() {
+			defer wg.Done()
+			for {
+				item, ok := receive(p, in)
+				if !ok || !send(p, out, item) {
+					return
+				}
+			}
+		})
+	}
+	p.goroutine(func() {
+		wg.Wait()
+		close(out)
+	})
+	return out
+}
+
+// Batch groups the items of in into slices of size items, the last one may be shorter
+func Batch[T any](p *Pipeline, in <-chan T, size int) <-chan []T {
+	if size < 1 {
+		size = 1
+	}
+
+	out := make(chan []T)
+	p.goroutine(func() {
+		defer close(out)
+		batch := make([]T, 0, size)
+		for {
+			item, ok := receive(p, in)
+			if !ok {
+				break
+			}
+			batch = append(batch, item)
+			if len(batch) == size {
+				if !send(p, out, batch) {
+					return
+				}
+				batch = make([]T, 0, size)
+			}
+		}
+		if len(batch) > 0 && p.ctx.Err() == nil {
+			send(p, out, batch)
+		}
+	})
+	return out
+}
+
+// Sink calls fn for every item of in on the calling goroutine, then waits for the pipeline.
+// It returns the first error of the pipeline, fn included.
+func Sink[T any](p *Pipeline, in <-chan T, fn func(T) error) error {
+	for {
+		item, ok := receive(p, in)
+		if !ok {
+			break
+		}
+		if err := fn(item); err != nil {
+			p.Fail(err)
+			break
+		}
+	}
+	return p.Wait()
+}
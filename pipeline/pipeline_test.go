@@ -0,0 +1,224 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/corentings/goTeaching/pipeline"
+)
+
+func integers(n int) []int {
+	items := make([]int, n)
+	for i := range items {
+		items[i] = i
+	}
+	return items
+}
+
+// jitterSquare squares the item after a random pause, so that the workers finish out of order
+func jitterSquare(_ context.Context, item int) (int, error) {
+	time.Sleep(time.Duration(rand.Intn(50)) * time.Microsecond)
+	return item * item, nil
+}
+
+func collect[T any](p *pipeline.Pipeline, in <-chan T) ([]T, error) {
+	var items []T
+	err := pipeline.Sink(p, in, func(item T) error {
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
+
+func checkNoLeak(t *testing.T, before int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("goroutine leak: %d goroutines before, %d after", before, after)
+	}
+}
+
+func TestStage(t *testing.T) {
+	expected := make([]int, 1000)
+	for i := range expected {
+		expected[i] = i * i
+	}
+
+	for _, workers := range []int{1, 4, 16} {
+		t.Run(fmt.Sprintf("ordered/%d", workers), func(t *testing.T) {
+			p := pipeline.New(context.Background())
+			squares := pipeline.Stage(p, pipeline.Generate(p, integers(1000)), workers, jitterSquare, pipeline.Ordered())
+			actual, err := collect(p, squares)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("results are not in input order")
+			}
+		})
+
+		t.Run(fmt.Sprintf("unordered/%d", workers), func(t *testing.T) {
+			p := pipeline.New(context.Background())
+			squares := pipeline.Stage(p, pipeline.Generate(p, integers(1000)), workers, jitterSquare, pipeline.Buffer(8))
+			actual, err := collect(p, squares)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			sort.Ints(actual)
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("unexpected results %v", actual)
+			}
+		})
+	}
+}
+
+func TestStageError(t *testing.T) {
+	errBoom := errors.New("boom")
+	before := runtime.NumGoroutine()
+
+	for _, opts := range [][]pipeline.StageOption{nil, {pipeline.Ordered()}} {
+		p := pipeline.New(context.Background())
+		squares := pipeline.Stage(p, pipeline.Generate(p, integers(10000)), 4, func(ctx context.Context, item int) (int, error) {
+			if item == 500 {
+				return 0, errBoom
+			}
+			return item * item, nil
+		}, opts...)
+		doubled := pipeline.Stage(p, squares, 2, func(_ context.Context, item int) (int, error) {
+			return 2 * item, nil
+		})
+
+		if _, err := collect(p, doubled); !errors.Is(err, errBoom) {
+			t.Errorf("expected %v got %v", errBoom, err)
+		}
+	}
+
+	checkNoLeak(t, before)
+}
+
+func TestSinkError(t *testing.T) {
+	errStop := errors.New("stop")
+	before := runtime.NumGoroutine()
+
+	p := pipeline.New(context.Background())
+	received := 0
+	err := pipeline.Sink(p, pipeline.Generate(p, integers(1000)), func(int) error {
+		received++
+		if received == 10 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || received != 10 {
+		t.Errorf("expected %v after 10 items, got %v after %d", errStop, err, received)
+	}
+
+	checkNoLeak(t, before)
+}
+
+func TestCancellation(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := pipeline.New(ctx)
+	slow := pipeline.Stage(p, pipeline.Generate(p, integers(1000000)), 4, func(ctx context.Context, item int) (int, error) {
+		return item, nil
+	}, pipeline.Ordered())
+
+	received := 0
+	err := pipeline.Sink(p, slow, func(int) error {
+		received++
+		if received == 100 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v got %v", context.Canceled, err)
+	}
+
+	checkNoLeak(t, before)
+}
+
+func TestFanOutFanIn(t *testing.T) {
+	p := pipeline.New(context.Background())
+
+	outs := pipeline.FanOut(p, pipeline.Generate(p, integers(1000)), 4)
+	squared := make([]<-chan int, len(outs))
+	for i, out := range outs {
+		squared[i] = pipeline.Stage(p, out, 1, jitterSquare)
+	}
+
+	actual, err := collect(p, pipeline.FanIn(p, squared...))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	sort.Ints(actual)
+	for i, square := range actual {
+		if square != i*i {
+			t.Fatalf("item %d is %d, expected %d", i, square, i*i)
+		}
+	}
+	if len(actual) != 1000 {
+		t.Errorf("received %d items, expected 1000", len(actual))
+	}
+}
+
+func TestBatch(t *testing.T) {
+	batchTests := []struct {
+		input    []int
+		size     int
+		expected [][]int
+		name     string
+	}{
+		{input: []int{}, size: 3, expected: nil, name: "empty"},
+		{input: []int{1, 2, 3, 4, 5, 6}, size: 3, expected: [][]int{{1, 2, 3}, {4, 5, 6}}, name: "exact"},
+		{input: []int{1, 2, 3, 4, 5}, size: 2, expected: [][]int{{1, 2}, {3, 4}, {5}}, name: "partial last batch"},
+		{input: []int{1, 2}, size: 0, expected: [][]int{{1}, {2}}, name: "size 0 behaves like 1"},
+	}
+
+	for _, test := range batchTests {
+		t.Run(test.name, func(t *testing.T) {
+			p := pipeline.New(context.Background())
+			actual, err := collect(p, pipeline.Batch(p, pipeline.Generate(p, test.input), test.size))
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("actual %v expected %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkStage(b *testing.B) {
+	square := func(_ context.Context, item int) (int, error) { return item * item, nil }
+	items := integers(100000)
+
+	for _, workers := range []int{1, runtime.NumCPU()} {
+		for _, ordered := range []bool{false, true} {
+			var opts []pipeline.StageOption
+			if ordered {
+				opts = append(opts, pipeline.Ordered())
+			}
+			b.Run(fmt.Sprintf("workers=%d/ordered=%v", workers, ordered), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					p := pipeline.New(context.Background())
+					batches := pipeline.Batch(p, pipeline.Stage(p, pipeline.Generate(p, items), workers, square, opts...), 1000)
+					_ = pipeline.Sink(p, batches, func([]int) error { return nil })
+				}
+			})
+		}
+	}
+}
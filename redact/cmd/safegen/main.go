@@ -0,0 +1,56 @@
+// Command safegen generates the safe counterpart of a struct, meant to be run by go generate:
+//
+//	//go:generate go run github.com/corentings/goTeaching/redact/cmd/safegen -type Pineapple -safe SafePineApple
+//
+// The fields are redacted according to their safe tags, see the redact package, and to the -omit, -mask and -hash flags.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/corentings/goTeaching/redact/gen"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("safegen: ")
+
+	cfg := gen.Config{}
+	flag.StringVar(&cfg.Type, "type", "", "struct to generate the safe counterpart of")
+	flag.StringVar(&cfg.SafeType, "safe", "", "name of the generated struct, Safe followed by the type by default")
+	omit := flag.String("omit", "", "comma separated fields to drop, in addition to the safe tags")
+	mask := flag.String("mask", "", "comma separated fields to mask, in addition to the safe tags")
+	hash := flag.String("hash", "", "comma separated fields to hash, in addition to the safe tags")
+	keepTags := flag.String("keep-tags", "json", "comma separated struct tag keys copied to the generated struct")
+	order := flag.String("order", "", "comma separated fields declared first in the generated struct, in this order")
+	output := flag.String("output", "", "output file, the lowercase type followed by _safe.go by default")
+	dir := flag.String("dir", ".", "directory of the package declaring the type")
+	flag.Parse()
+
+	if cfg.Type == "" {
+		log.Fatal("-type is required")
+	}
+	cfg.Omit, cfg.Mask, cfg.Hash, cfg.KeepTags, cfg.Order = split(*omit), split(*mask), split(*hash), split(*keepTags), split(*order)
+
+	if *output == "" {
+		*output = strings.ToLower(cfg.Type) + "_safe.go"
+	}
+
+	src, err := gen.GenerateDir(*dir, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func split(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
@@ -0,0 +1,369 @@
+// Package gen generates the safe counterpart of a struct at build time, from the same safe tags as the redact package:
+// a struct type without the omitted fields, a ToSafe method and a bulk ToSafe...Slice function.
+// The generated code copies the fields by hand, so it costs nothing more than a hand written conversion.
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// ErrTypeNotFound is returned when the source struct is not declared in the parsed files
+var ErrTypeNotFound = errors.New("gen: struct type not found")
+
+// Config selects the source struct and the names of the generated code.
+// Omit, Mask and Hash add rules to the safe tags of the fields, for structs which can't be tagged.
+type Config struct {
+	Type     string   // source struct, e.g. Pineapple
+	SafeType string   // generated struct, "Safe" + Type by default
+	Omit     []string // fields dropped
+	Mask     []string // fields replaced by redact.Mask
+	Hash     []string // fields replaced by their SHA-256
+	KeepTags []string // struct tag keys copied to the generated struct, such as json
+	Order    []string // generated fields declared first, in this order, the others follow in source order
+}
+
+// GenerateDir parses the non test Go files of dir and generates the safe code of cfg.Type
+func GenerateDir(dir string, cfg Config) ([]byte, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		file, err := parser.ParseFile(fset, path, src, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	return Generate(fset, files, cfg)
+}
+
+type fieldCode struct {
+	Name  string
+	Type  string // in the generated struct
+	Tag   string
+	Value string // expression of the value, the source struct being the receiver
+}
+
+type fileCode struct {
+	Package  string
+	Imports  [][]string // standard library first, then the other imports
+	Type     string
+	SafeType string
+	Receiver string
+	Fields   []fieldCode
+}
+
+// Generate returns the formatted source of the safe code of cfg.Type, declared in one of files
+func Generate(fset *token.FileSet, files []*ast.File, cfg Config) ([]byte, error) {
+	file, spec := findStruct(files, cfg.Type)
+	if spec == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTypeNotFound, cfg.Type)
+	}
+
+	code := fileCode{
+		Package:  file.Name.Name,
+		Type:     cfg.Type,
+		SafeType: cfg.SafeType,
+		Receiver: strings.ToLower(cfg.Type[:1]),
+	}
+	if code.SafeType == "" {
+		code.SafeType = "Safe" + cfg.Type
+	}
+
+	rules := make(map[string]string)
+	for rule, names := range map[string][]string{"omit": cfg.Omit, "mask": cfg.Mask, "hash": cfg.Hash} {
+		for _, name := range names {
+			rules[name] = rule
+		}
+	}
+
+	imports := make(map[string]bool)
+	for _, field := range spec.Fields.List {
+		if len(field.Names) == 0 {
+			return nil, fmt.Errorf("gen: embedded field %s in %s is not supported", exprString(fset, field.Type), cfg.Type)
+		}
+
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			raw, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return nil, err
+			}
+			tag = reflect.StructTag(raw)
+		}
+
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+
+			rule, ok := rules[name.Name]
+			if !ok {
+				rule = tag.Get("safe")
+			}
+
+			value := code.Receiver + "." + name.Name
+			f := fieldCode{Name: name.Name, Type: "string", Tag: keptTags(tag, cfg.KeepTags)}
+			switch rule {
+			case "":
+				f.Type, f.Value = exprString(fset, field.Type), value
+				for _, pkg := range packagesOf(field.Type) {
+					spec, err := importSpec(file, pkg)
+					if err != nil {
+						return nil, err
+					}
+					imports[spec] = true
+				}
+			case "omit":
+				continue
+			case "mask":
+				f.Value = "redact.Mask"
+				imports[redactImport] = true
+			case "hash":
+				f.Value = hashExpr(field.Type, value, imports)
+			default:
+				return nil, fmt.Errorf("gen: invalid safe tag %q on %s.%s", rule, cfg.Type, name.Name)
+			}
+			code.Fields = append(code.Fields, f)
+		}
+	}
+
+	fields, err := orderFields(code.Fields, cfg.Order)
+	if err != nil {
+		return nil, fmt.Errorf("gen: invalid order of %s: %w", code.SafeType, err)
+	}
+	code.Fields = fields
+	code.Imports = groupImports(imports)
+
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, code); err != nil {
+		return nil, err
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("gen: invalid generated code: %w\n%s", err, buf.Bytes())
+	}
+	return formatted, nil
+}
+
+// orderFields moves the fields named in order first, it fails on a name which isn't a generated field
+func orderFields(fields []fieldCode, order []string) ([]fieldCode, error) {
+	if len(order) == 0 {
+		return fields, nil
+	}
+
+	byName := make(map[string]fieldCode, len(fields))
+	for _, f := range fields {
+		byName[f.Name] = f
+	}
+	ordered := make([]fieldCode, 0, len(fields))
+	listed := make(map[string]bool, len(order))
+	for _, name := range order {
+		f, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not a generated field", name)
+		}
+		if listed[name] {
+			return nil, fmt.Errorf("%s is listed twice", name)
+		}
+		listed[name] = true
+		ordered = append(ordered, f)
+	}
+	for _, f := range fields {
+		if !listed[f.Name] {
+			ordered = append(ordered, f)
+		}
+	}
+	return ordered, nil
+}
+
+func findStruct(files []*ast.File, name string) (*ast.File, *ast.StructType) {
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				if ts := spec.(*ast.TypeSpec); ts.Name.Name == name {
+					if st, ok := ts.Type.(*ast.StructType); ok {
+						return file, st
+					}
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// hashExpr hashes strings and byte slices directly, and the default format of other types like redact does
+func hashExpr(typ ast.Expr, value string, imports map[string]bool) string {
+	imports[redactImport] = true
+
+	if ident, ok := typ.(*ast.Ident); ok && ident.Name == "string" {
+		return "redact.HashString(" + value + ")"
+	}
+	if array, ok := typ.(*ast.ArrayType); ok && array.Len == nil {
+		if ident, ok := array.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") {
+			return "redact.HashBytes(" + value + ")"
+		}
+	}
+
+	imports[`"fmt"`] = true
+	return "redact.HashString(fmt.Sprint(" + value + "))"
+}
+
+func keptTags(tag reflect.StructTag, keys []string) string {
+	var kept []string
+	for _, key := range keys {
+		if value, ok := tag.Lookup(key); ok {
+			kept = append(kept, key+":"+strconv.Quote(value))
+		}
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	return "`" + strings.Join(kept, " ") + "`"
+}
+
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = printer.Fprint(&buf, fset, expr)
+	return buf.String()
+}
+
+// packagesOf returns the package names used by a type expression, such as time in time.Time
+func packagesOf(expr ast.Expr) []string {
+	var pkgs []string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				pkgs = append(pkgs, ident.Name)
+			}
+			return false
+		}
+		return true
+	})
+	return pkgs
+}
+
+// groupImports splits the import specs between the standard library and the other packages, like goimports
+func groupImports(imports map[string]bool) [][]string {
+	var std, other []string
+	for spec := range imports {
+		path := spec[strings.IndexByte(spec, '"'):]
+		if first, _, _ := strings.Cut(strings.Trim(path, `"`), "/"); strings.Contains(first, ".") {
+			other = append(other, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	var groups [][]string
+	for _, group := range [][]string{std, other} {
+		if len(group) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+const redactImport = `"github.com/corentings/goTeaching/redact"`
+
+var versionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// importSpec returns the import of the file using the package name pkg, as written in an import block
+func importSpec(file *ast.File, pkg string) (string, error) {
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return "", err
+		}
+
+		name := spec.Name.String()
+		if spec.Name == nil {
+			parts := strings.Split(path, "/")
+			name = parts[len(parts)-1]
+			if versionSuffix.MatchString(name) && len(parts) > 1 {
+				name = parts[len(parts)-2]
+			}
+		}
+		if name == pkg {
+			if spec.Name != nil {
+				return spec.Name.Name + " " + spec.Path.Value, nil
+			}
+			return spec.Path.Value, nil
+		}
+	}
+	return "", fmt.Errorf("gen: no import for package %s", pkg)
+}
+
+var fileTemplate = template.Must(template.New("safe").Parse(`// Code generated by safegen from {{.Type}}; DO NOT EDIT.
+
+package {{.Package}}
+{{if .Imports}}
+import (
+{{- range $i, $group := .Imports}}{{if $i}}
+{{end}}
+{{- range $group}}
+	{{.}}
+{{- end}}
+{{- end}}
+)
+{{end}}
+// {{.SafeType}} is a {{.Type}} without its sensitive fields
+type {{.SafeType}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} {{.Tag}}
+{{- end}}
+}
+
+// To{{.SafeType}} converts a {{.Type}} to a {{.SafeType}}
+func ({{.Receiver}} *{{.Type}}) To{{.SafeType}}() {{.SafeType}} {
+	return {{.SafeType}}{
+{{- range .Fields}}
+		{{.Name}}: {{.Value}},
+{{- end}}
+	}
+}
+
+// To{{.SafeType}}Slice converts every {{.Type}} to a {{.SafeType}}, keeping their order
+func To{{.SafeType}}Slice(items []{{.Type}}) []{{.SafeType}} {
+	safeItems := make([]{{.SafeType}}, len(items))
+	for i := range items {
+		safeItems[i] = items[i].To{{.SafeType}}()
+	}
+	return safeItems
+}
+`))
@@ -0,0 +1,85 @@
+package gen
+
+import (
+	"errors"
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		golden string
+		cfg    Config
+	}{
+		{"user_safe.golden", Config{Type: "User", KeepTags: []string{"json"}}},
+		{"user_config.golden", Config{Type: "User", SafeType: "PublicUser", Omit: []string{"Address", "Tags"}, Hash: []string{"Email"}}},
+		{"user_order.golden", Config{Type: "User", Order: []string{"Created", "Name", "ID"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.golden, func(t *testing.T) {
+			actual, err := GenerateDir(filepath.Join("testdata", "user"), test.cfg)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			path := filepath.Join("testdata", test.golden)
+			if *update {
+				if err := os.WriteFile(path, actual, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			expected, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(actual) != string(expected) {
+				t.Errorf("generated code differs from %s, run go test -update to accept it:\n%s", path, actual)
+			}
+		})
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	parse := func(src string) (*token.FileSet, []*ast.File) {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "src.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fset, []*ast.File{file}
+	}
+
+	fset, files := parse("package p\ntype A struct{ X int }\ntype B int\n")
+	for _, name := range []string{"Missing", "B"} {
+		if _, err := Generate(fset, files, Config{Type: name}); !errors.Is(err, ErrTypeNotFound) {
+			t.Errorf("%s: expected %v got %v", name, ErrTypeNotFound, err)
+		}
+	}
+
+	for name, src := range map[string]string{
+		"invalid tag":    "package p\ntype A struct{ X int `safe:\"encrypt\"` }\n",
+		"embedded field": "package p\ntype B struct{}\ntype A struct{ B }\n",
+		"missing import": "package p\ntype A struct{ X time.Time }\n",
+	} {
+		fset, files := parse(src)
+		if _, err := Generate(fset, files, Config{Type: "A"}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	fset, files = parse("package p\ntype A struct{ X, Y int\n Z int `safe:\"omit\"` }\n")
+	for _, order := range [][]string{{"Y", "W"}, {"Z"}, {"Y", "X", "Y"}} {
+		if _, err := Generate(fset, files, Config{Type: "A", Order: order}); err == nil {
+			t.Errorf("order %v: expected an error", order)
+		}
+	}
+}
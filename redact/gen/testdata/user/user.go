@@ -0,0 +1,18 @@
+package user
+
+import (
+	"net/netip"
+	stdtime "time"
+)
+
+type User struct {
+	ID          int
+	Name, Email string `safe:"mask"`
+	Password    string `json:"-" safe:"omit"`
+	Token       []byte `json:"token" safe:"hash"`
+	Age         int    `safe:"hash"`
+	Address     netip.Addr
+	Created     stdtime.Time `json:"created"`
+	Tags        map[string][]string
+	internal    string
+}
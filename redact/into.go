@@ -0,0 +1,89 @@
+package redact
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// copyField copies one safe field of the source struct into a field of the destination struct
+type copyField struct {
+	src   field
+	dst   int
+	value bool // the field is copied as it is, otherwise it is the string of a mask or a hash
+}
+
+type intoKey struct{ dst, src reflect.Type }
+
+var intoPlans sync.Map // intoKey -> []copyField
+
+// Into redacts src and copies its safe fields into the fields of the same name of the struct dst points to.
+// The fields of dst which have no safe counterpart in src are left as they are, so that omitted data can't be copied.
+// Masked and hashed fields need a string field in dst.
+func Into(dst, src any) error {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() || dv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: destination %T is not a pointer to a struct", ErrNotStruct, dst)
+	}
+	dv = dv.Elem()
+
+	sv, err := structValue(src)
+	if err != nil {
+		return err
+	}
+
+	fields, err := intoPlanOf(dv.Type(), sv.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		target := dv.Field(f.dst)
+		if f.value {
+			target.Set(sv.Field(f.src.index))
+		} else {
+			value, _ := f.src.redactValue(sv.Field(f.src.index), nil) // a mask or a hash, which can't fail
+			target.SetString(value.(string))
+		}
+	}
+	return nil
+}
+
+// intoPlanOf matches the safe fields of src with the fields of dst, the result is cached per pair of types
+func intoPlanOf(dst, src reflect.Type) ([]copyField, error) {
+	key := intoKey{dst, src}
+	if fields, ok := intoPlans.Load(key); ok {
+		return fields.([]copyField), nil
+	}
+
+	p, err := planOf(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []copyField
+	for _, f := range p.fields {
+		sf, ok := dst.FieldByName(f.name)
+		if !ok || len(sf.Index) != 1 || !sf.IsExported() {
+			continue
+		}
+
+		c := copyField{src: f, dst: sf.Index[0]}
+		switch {
+		case f.action == mask || f.action == hash:
+			if sf.Type.Kind() != reflect.String {
+				return nil, fmt.Errorf("%w: %s.%s must be a string to hold a redacted %s.%s", ErrIncompatibleField, dst, sf.Name, src, f.name)
+			}
+		case f.nested != nil:
+			return nil, fmt.Errorf("%w: %s.%s holds sensitive fields, redact it on its own", ErrIncompatibleField, src, f.name)
+		case src.Field(f.index).Type.AssignableTo(sf.Type):
+			c.value = true
+		default:
+			return nil, fmt.Errorf("%w: %s.%s can't hold %s.%s of type %s", ErrIncompatibleField, dst, sf.Name, src, f.name, src.Field(f.index).Type)
+		}
+		fields = append(fields, c)
+	}
+
+	actual, _ := intoPlans.LoadOrStore(key, fields)
+	return actual.([]copyField), nil
+}
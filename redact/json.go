@@ -0,0 +1,351 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+type policyKind int
+
+const (
+	policyKeep policyKind = iota
+	policyOmit
+	policyMask
+	policyLast4
+	policyHash
+	policyTruncate
+)
+
+// Policy is how a field is written by MarshalJSON
+type Policy struct {
+	kind   policyKind
+	length int // of Truncate
+}
+
+var (
+	// Keep writes the field as encoding/json does
+	Keep = Policy{kind: policyKeep}
+	// Omit drops the field
+	Omit = Policy{kind: policyOmit}
+	// MaskAll writes Mask instead of the value
+	MaskAll = Policy{kind: policyMask}
+	// MaskLast4 writes Mask followed by the last 4 characters, such as "****1234". Values of 4 characters or less are fully masked.
+	MaskLast4 = Policy{kind: policyLast4}
+	// Hash writes the hex encoded SHA-256 of the value
+	Hash = Policy{kind: policyHash}
+)
+
+// Truncate writes the first n characters of the value followed by "…" when it is longer
+func Truncate(n int) Policy {
+	if n < 0 {
+		n = 0
+	}
+	return Policy{kind: policyTruncate, length: n}
+}
+
+// String returns the name of the policy
+func (p Policy) String() string {
+	switch p.kind {
+	case policyKeep:
+		return "keep"
+	case policyOmit:
+		return "omit"
+	case policyMask:
+		return "mask"
+	case policyLast4:
+		return "last4"
+	case policyHash:
+		return "hash"
+	default:
+		return fmt.Sprintf("truncate(%d)", p.length)
+	}
+}
+
+// apply returns the redacted text of v, strings and byte slices are used as they are and other values formatted
+func (p Policy) apply(v reflect.Value) string {
+	var text string
+	switch {
+	case v.Kind() == reflect.String:
+		text = v.String()
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		text = string(v.Bytes())
+	default:
+		text = fmt.Sprint(v.Interface())
+	}
+
+	switch p.kind {
+	case policyLast4:
+		if utf8.RuneCountInString(text) <= 4 {
+			return Mask
+		}
+		runes := []rune(text)
+		return Mask + string(runes[len(runes)-4:])
+	case policyHash:
+		return HashString(text)
+	case policyTruncate:
+		if utf8.RuneCountInString(text) <= p.length {
+			return text
+		}
+		return string([]rune(text)[:p.length]) + "…"
+	default:
+		return Mask
+	}
+}
+
+// Policies holds the policies of the fields of a type, by Go field name.
+// They can be changed at any time, even while values are being encoded.
+// A field without a policy follows its safe tag: omit, mask or hash, and is kept when it has none.
+type Policies struct {
+	mu     sync.RWMutex
+	fields map[string]Policy
+}
+
+// NewPolicies returns the policies of the given fields
+func NewPolicies(fields map[string]Policy) *Policies {
+	p := &Policies{fields: make(map[string]Policy, len(fields))}
+	for name, policy := range fields {
+		p.fields[name] = policy
+	}
+	return p
+}
+
+// Set changes the policy of a field
+func (p *Policies) Set(field string, policy Policy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fields[field] = policy
+}
+
+// Reset removes the policy of a field, which follows its safe tag again
+func (p *Policies) Reset(field string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.fields, field)
+}
+
+// Snapshot returns a copy of the policies set for the fields, to be restored later
+func (p *Policies) Snapshot() map[string]Policy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	snapshot := make(map[string]Policy, len(p.fields))
+	for name, policy := range p.fields {
+		snapshot[name] = policy
+	}
+	return snapshot
+}
+
+// Restore replaces every policy with those of the snapshot, the fields it doesn't hold follow their safe tag again
+func (p *Policies) Restore(snapshot map[string]Policy) {
+	fields := make(map[string]Policy, len(snapshot))
+	for name, policy := range snapshot {
+		fields[name] = policy
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fields = fields
+}
+
+// Get returns the policy set for a field
+func (p *Policies) Get(field string) (Policy, bool) {
+	if p == nil {
+		return Policy{}, false
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	policy, ok := p.fields[field]
+	return policy, ok
+}
+
+// jsonField is an exported field as encoding/json sees it
+type jsonField struct {
+	index     int
+	name      string // Go name, the key of the policies
+	key       []byte // encoded JSON name
+	omitEmpty bool
+	tag       Policy // from the safe tag
+	nested    *shape // for fields holding structs with safe tags, encoded by MarshalJSON when kept
+}
+
+var jsonFields sync.Map // reflect.Type -> []jsonField
+
+func jsonFieldsOf(t reflect.Type) ([]jsonField, error) {
+	if fields, ok := jsonFields.Load(t); ok {
+		return fields.([]jsonField), nil
+	}
+
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" && options == "" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+
+		f := jsonField{index: i, name: sf.Name, key: key, omitEmpty: strings.Contains(","+options+",", ",omitempty,")}
+		switch tag := sf.Tag.Get("safe"); tag {
+		case "":
+			f.tag = Keep
+		case "omit":
+			f.tag = Omit
+		case "mask":
+			f.tag = MaskAll
+		case "hash":
+			f.tag = Hash
+		default:
+			return nil, fmt.Errorf("%w %q on %s.%s", ErrInvalidTag, tag, t, sf.Name)
+		}
+		if f.nested, err = shapeOf(sf.Type, map[reflect.Type]*plan{}); err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+
+	actual, _ := jsonFields.LoadOrStore(t, fields)
+	return actual.([]jsonField), nil
+}
+
+// isEmpty is the omitempty rule of encoding/json
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	default:
+		return false
+	}
+}
+
+// MarshalJSON encodes the exported fields of the struct v as a JSON object, applying the policy of every field.
+// Each field is encoded on its own, so a type can implement json.Marshaler with it without recursing forever.
+// The policies only apply to the fields of v: the kept structs with safe tags it holds, directly or through pointers,
+// slices, arrays and maps, follow their tags unless they implement json.Marshaler themselves.
+func MarshalJSON(v any, policies *Policies) ([]byte, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return marshalStruct(rv, policies, walkPath{})
+}
+
+func marshalStruct(rv reflect.Value, policies *Policies, path walkPath) ([]byte, error) {
+	fields, err := jsonFieldsOf(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, f := range fields {
+		policy, ok := policies.Get(f.name)
+		if !ok {
+			policy = f.tag
+		}
+		if policy.kind == policyOmit {
+			continue
+		}
+
+		value := rv.Field(f.index)
+		if f.omitEmpty && isEmpty(value) {
+			continue
+		}
+
+		var data []byte
+		switch {
+		case policy.kind != policyKeep:
+			data, err = json.Marshal(policy.apply(value))
+		case f.nested != nil:
+			data, err = marshalNested(value, f.nested, path)
+		default:
+			data, err = json.Marshal(value.Interface())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("redact: field %s: %w", f.name, err)
+		}
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(f.key)
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+var (
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// marshalNested encodes a kept value holding structs with safe tags, walking it as described by s
+func marshalNested(v reflect.Value, s *shape, path walkPath) ([]byte, error) {
+	switch {
+	case v.Type().Implements(marshalerType):
+		return json.Marshal(v.Interface())
+	case v.CanAddr() && v.Addr().Type().Implements(marshalerType):
+		return json.Marshal(v.Addr().Interface())
+	case s.kind == reflect.Struct:
+		return marshalStruct(v, nil, path)
+	}
+
+	if s.kind != reflect.Array {
+		if v.IsNil() {
+			return []byte("null"), nil
+		}
+		leave, err := path.enter(v)
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+	}
+
+	switch s.kind {
+	case reflect.Pointer:
+		return marshalNested(v.Elem(), s.elem, path)
+	case reflect.Map:
+		// encoding/json encodes and sorts the keys
+		m := reflect.MakeMapWithSize(reflect.MapOf(v.Type().Key(), rawMessageType), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			data, err := marshalNested(iter.Value(), s.elem, path)
+			if err != nil {
+				return nil, err
+			}
+			m.SetMapIndex(iter.Key(), reflect.ValueOf(json.RawMessage(data)))
+		}
+		return json.Marshal(m.Interface())
+	default:
+		elems := make([]json.RawMessage, v.Len())
+		for i := range elems {
+			data, err := marshalNested(v.Index(i), s.elem, path)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = data
+		}
+		return json.Marshal(elems)
+	}
+}
@@ -0,0 +1,208 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+)
+
+type account struct {
+	Owner    string `json:"owner"`
+	Card     string `json:"card,omitempty"`
+	PIN      int    `safe:"omit"`
+	Email    string `safe:"mask"`
+	Token    []byte `safe:"hash"`
+	Note     string `json:"-"`
+	Balance  float64
+	internal string
+}
+
+func testAccount() account {
+	return account{Owner: "Ada Lovelace", Card: "4970101234567890", PIN: 1234, Email: "ada@example.com", Token: []byte("token"), Note: "note", Balance: 12.5, internal: "internal"}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	a := testAccount()
+
+	tests := []struct {
+		name     string
+		policies map[string]Policy
+		expected string
+	}{
+		{"tags", nil,
+			`{"owner":"Ada Lovelace","card":"4970101234567890","Email":"****","Token":"` + HashString("token") + `","Balance":12.5}`},
+		{"last 4", map[string]Policy{"Card": MaskLast4},
+			`{"owner":"Ada Lovelace","card":"****7890","Email":"****","Token":"` + HashString("token") + `","Balance":12.5}`},
+		{"runtime policies override the tags", map[string]Policy{"Owner": Truncate(3), "PIN": Hash, "Email": Keep, "Token": Omit, "Balance": MaskAll},
+			`{"owner":"Ada…","card":"4970101234567890","PIN":"` + HashString("1234") + `","Email":"ada@example.com","Balance":"****"}`},
+		{"short values are fully masked", map[string]Policy{"PIN": MaskLast4, "Owner": Truncate(100)},
+			`{"owner":"Ada Lovelace","card":"4970101234567890","PIN":"****","Email":"****","Token":"` + HashString("token") + `","Balance":12.5}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := MarshalJSON(&a, NewPolicies(test.policies))
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if string(data) != test.expected {
+				t.Errorf("actual   %s\nexpected %s", data, test.expected)
+			}
+			if !json.Valid(data) {
+				t.Errorf("invalid JSON %s", data)
+			}
+		})
+	}
+
+	a.Card = ""
+	if data, _ := MarshalJSON(a, nil); string(data) != `{"owner":"Ada Lovelace","Email":"****","Token":"`+HashString("token")+`","Balance":12.5}` {
+		t.Errorf("omitempty not applied: %s", data)
+	}
+}
+
+// safeCard implements json.Marshaler, which is used rather than its safe tags
+type safeCard struct {
+	Number string `safe:"omit"`
+}
+
+func (c safeCard) MarshalJSON() ([]byte, error) {
+	return []byte(`"card"`), nil
+}
+
+func TestMarshalJSONNested(t *testing.T) {
+	type wallet struct {
+		Main      account
+		Backup    *account
+		Old       []account
+		Pair      [2]*account
+		ByBank    map[string]account
+		Card      safeCard
+		None      *account
+		Empty     []account `json:",omitempty"`
+		Forgotten account   `safe:"omit"`
+	}
+
+	a := testAccount()
+	kept := `{"owner":"Ada Lovelace","card":"4970101234567890","Email":"****","Token":"` + HashString("token") + `","Balance":12.5}`
+	w := wallet{Main: a, Backup: &a, Old: []account{a}, Pair: [2]*account{&a, nil}, ByBank: map[string]account{"b": a, "a": a}, Forgotten: a}
+
+	tests := []struct {
+		name     string
+		policies map[string]Policy
+		expected string
+	}{
+		{"tags", nil,
+			`{"Main":` + kept + `,"Backup":` + kept + `,"Old":[` + kept + `],"Pair":[` + kept + `,null],` +
+				`"ByBank":{"a":` + kept + `,"b":` + kept + `},"Card":"card","None":null}`},
+		{"the policies only apply to the outer fields", map[string]Policy{"Email": Keep, "Main": Omit, "Old": MaskAll, "Pair": Omit, "ByBank": Omit},
+			`{"Backup":` + kept + `,"Old":"****","Card":"card","None":null}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := MarshalJSON(w, NewPolicies(test.policies))
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if string(data) != test.expected {
+				t.Errorf("actual   %s\nexpected %s", data, test.expected)
+			}
+			if bytes.Contains(data, []byte(a.Email)) || bytes.Contains(data, []byte("PIN")) {
+				t.Errorf("leaked sensitive data %s", data)
+			}
+		})
+	}
+
+	type cyclic struct {
+		Account account
+		Next    *cyclic
+	}
+	c := &cyclic{Account: a}
+	c.Next = c
+	if _, err := MarshalJSON(c, nil); !errors.Is(err, ErrCycle) {
+		t.Errorf("expected %v got %v", ErrCycle, err)
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	policies := NewPolicies(map[string]Policy{"Card": Omit})
+	if policy, ok := policies.Get("Card"); !ok || policy != Omit {
+		t.Errorf("Card policy %v %v expected omit", policy, ok)
+	}
+
+	policies.Set("Card", MaskLast4)
+	if policy, _ := policies.Get("Card"); policy != MaskLast4 {
+		t.Errorf("Card policy %v expected last4", policy)
+	}
+
+	policies.Reset("Card")
+	if _, ok := policies.Get("Card"); ok {
+		t.Error("Card policy should be reset")
+	}
+
+	snapshot := policies.Snapshot()
+	policies.Set("Email", Keep)
+	snapshot["Token"] = Omit // the snapshot is a copy
+	if policy, ok := policies.Get("Token"); ok {
+		t.Errorf("Token policy %v changed through the snapshot", policy)
+	}
+	policies.Restore(map[string]Policy{"Card": Hash})
+	if _, ok := policies.Get("Email"); ok {
+		t.Error("Email policy should be gone after Restore")
+	}
+	if policy, _ := policies.Get("Card"); policy != Hash {
+		t.Errorf("Card policy %v expected hash", policy)
+	}
+
+	for policy, expected := range map[Policy]string{Keep: "keep", Omit: "omit", MaskAll: "mask", MaskLast4: "last4", Hash: "hash", Truncate(8): "truncate(8)", Truncate(-1): "truncate(0)"} {
+		if policy.String() != expected {
+			t.Errorf("policy %s expected %s", policy, expected)
+		}
+	}
+}
+
+func TestPoliciesConcurrentChanges(t *testing.T) {
+	policies := NewPolicies(nil)
+	a := testAccount()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := MarshalJSON(a, policies); err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				policies.Set("Card", MaskAll)
+				policies.Reset("Card")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMarshalJSONErrors(t *testing.T) {
+	if _, err := MarshalJSON("account", nil); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("expected %v got %v", ErrNotStruct, err)
+	}
+
+	type invalid struct {
+		Secret string `safe:"encrypt"`
+	}
+	if _, err := MarshalJSON(invalid{}, nil); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("expected %v got %v", ErrInvalidTag, err)
+	}
+
+	type unsupported struct{ C chan int }
+	if _, err := MarshalJSON(unsupported{}, nil); err == nil {
+		t.Error("expected an error for a channel")
+	}
+}
@@ -0,0 +1,323 @@
+// Package redact derives safe values from structs holding sensitive data, driven by struct tags:
+//
+//	type User struct {
+//		Name     string
+//		Email    string `safe:"mask"` // replaced by "****"
+//		Password string `safe:"omit"` // dropped
+//		Token    string `safe:"hash"` // replaced by its hex encoded SHA-256
+//	}
+//
+// Untagged exported fields are copied as they are, unless they hold structs with safe tags themselves,
+// directly or through pointers, slices, arrays and maps: those are redacted too. The plan of a type, which fields to keep and how,
+// is built once with reflection and cached, so redacting many values of the same type stays cheap.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Mask replaces the value of the fields tagged safe:"mask", it doesn't depend on the value so it leaks nothing of it
+const Mask = "****"
+
+var (
+	// ErrNotStruct is returned for values which are neither a struct nor a pointer to a struct
+	ErrNotStruct = errors.New("redact: not a struct")
+	// ErrInvalidTag is returned for a safe tag which is not omit, mask or hash
+	ErrInvalidTag = errors.New("redact: invalid safe tag")
+	// ErrIncompatibleField is returned by Into when a field can't hold the redacted value
+	ErrIncompatibleField = errors.New("redact: incompatible field")
+	// ErrCycle is returned for a value which references itself through its pointers, slices or maps
+	ErrCycle = errors.New("redact: cyclic value")
+)
+
+type action int
+
+const (
+	keep action = iota
+	omit
+	mask
+	hash
+)
+
+// field is how a field of a struct is redacted
+type field struct {
+	index  int
+	name   string
+	action action
+	nested *shape // for kept fields holding structs with sensitive data themselves
+}
+
+// plan lists the fields of a struct type which are not omitted
+type plan struct {
+	fields []field
+}
+
+// shape is how a kept value is walked down to the structs with sensitive data it holds,
+// through pointers, slices, arrays and maps
+type shape struct {
+	kind reflect.Kind // Struct, Pointer, Slice, Array or Map
+	plan *plan        // of a Struct
+	elem *shape       // of the others
+}
+
+var plans sync.Map // reflect.Type -> *plan
+
+// planOf returns the cached plan of the struct type t
+func planOf(t reflect.Type) (*plan, error) {
+	if p, ok := plans.Load(t); ok {
+		return p.(*plan), nil
+	}
+
+	p, err := buildPlan(t, map[reflect.Type]*plan{})
+	if err != nil {
+		return nil, err
+	}
+
+	actual, _ := plans.LoadOrStore(t, p)
+	return actual.(*plan), nil
+}
+
+// buildPlan builds the plan of t, building holds the plans being built so that self referencing types share them
+func buildPlan(t reflect.Type, building map[reflect.Type]*plan) (*plan, error) {
+	p := &plan{}
+	building[t] = p
+	defer delete(building, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		f := field{index: i, name: sf.Name}
+		switch tag := sf.Tag.Get("safe"); tag {
+		case "":
+			f.action = keep
+		case "omit":
+			continue
+		case "mask":
+			f.action = mask
+		case "hash":
+			f.action = hash
+		default:
+			return nil, fmt.Errorf("%w %q on %s.%s", ErrInvalidTag, tag, t, sf.Name)
+		}
+
+		if f.action == keep {
+			nested, err := shapeOf(sf.Type, building)
+			if err != nil {
+				return nil, err
+			}
+			f.nested = nested
+		}
+
+		p.fields = append(p.fields, f)
+	}
+
+	return p, nil
+}
+
+// shapeOf returns how to walk the values of type t, nil when they hold no struct with sensitive data
+func shapeOf(t reflect.Type, building map[reflect.Type]*plan) (*shape, error) {
+	if !sensitive(t, map[reflect.Type]bool{}) {
+		return nil, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if p, ok := building[t]; ok {
+			return &shape{kind: reflect.Struct, plan: p}, nil
+		}
+		p, err := buildPlan(t, building)
+		if err != nil {
+			return nil, err
+		}
+		return &shape{kind: reflect.Struct, plan: p}, nil
+	default:
+		elem, err := shapeOf(t.Elem(), building)
+		if err != nil {
+			return nil, err
+		}
+		return &shape{kind: t.Kind(), elem: elem}, nil
+	}
+}
+
+// sensitive reports whether t has a field with a safe tag, directly or through its structs, pointers, slices, arrays and maps.
+// Interfaces are not looked into, their dynamic type isn't known before the value.
+func sensitive(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return sensitive(t.Elem(), visiting)
+	case reflect.Struct:
+		if visiting[t] {
+			return false
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			if _, ok := sf.Tag.Lookup("safe"); ok || sensitive(sf.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// structValue dereferences v until it finds a struct
+func structValue(v any) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return reflect.Value{}, fmt.Errorf("%w: nil pointer", ErrNotStruct)
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%w: %T", ErrNotStruct, v)
+	}
+	return rv, nil
+}
+
+// redactValue returns the safe value of a field
+func (f field) redactValue(v reflect.Value, path walkPath) (any, error) {
+	switch {
+	case f.action == mask:
+		return Mask, nil
+	case f.action == hash:
+		return hashValue(v), nil
+	case f.nested != nil:
+		return f.nested.redact(v, path)
+	default:
+		return v.Interface(), nil
+	}
+}
+
+// reference identifies a pointer, a slice or a map being walked
+type reference struct {
+	ptr uintptr
+	typ reflect.Type
+	len int // of a slice, its first elements are another slice
+}
+
+// walkPath holds the references from the root of the walk to the current value, a value met twice on it is cyclic
+type walkPath map[reference]bool
+
+// enter adds the reference v to the path, the returned function removes it
+func (path walkPath) enter(v reflect.Value) (func(), error) {
+	ref := reference{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		ref.len = v.Len()
+	}
+	if path[ref] {
+		return nil, fmt.Errorf("%w: %s", ErrCycle, v.Type())
+	}
+	path[ref] = true
+	return func() { delete(path, ref) }, nil
+}
+
+var anyType = reflect.TypeOf((*any)(nil)).Elem()
+
+// redact returns the safe value of v: a map for a struct, a slice of the safe elements for a slice or an array,
+// and a map of the same keys for a map. Nil pointers, slices and maps are nil.
+func (s *shape) redact(v reflect.Value, path walkPath) (any, error) {
+	if s.kind == reflect.Struct {
+		return s.plan.toMap(v, path)
+	}
+	if s.kind != reflect.Array {
+		if v.IsNil() {
+			return nil, nil
+		}
+		leave, err := path.enter(v)
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+	}
+
+	switch s.kind {
+	case reflect.Pointer:
+		return s.elem.redact(v.Elem(), path)
+	case reflect.Map:
+		m := reflect.MakeMapWithSize(reflect.MapOf(v.Type().Key(), anyType), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			elem, err := s.elem.redact(iter.Value(), path)
+			if err != nil {
+				return nil, err
+			}
+			m.SetMapIndex(iter.Key(), reflect.ValueOf(&elem).Elem())
+		}
+		return m.Interface(), nil
+	default:
+		elems := make([]any, v.Len())
+		for i := range elems {
+			elem, err := s.elem.redact(v.Index(i), path)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = elem
+		}
+		return elems, nil
+	}
+}
+
+// hashValue hashes the bytes of strings and byte slices, and the default format of other values
+func hashValue(v reflect.Value) string {
+	switch {
+	case v.Kind() == reflect.String:
+		return HashString(v.String())
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return HashBytes(v.Bytes())
+	default:
+		return HashString(fmt.Sprint(v.Interface()))
+	}
+}
+
+// HashBytes returns the hex encoded SHA-256 of data, as used for the fields tagged safe:"hash"
+func HashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// HashString is HashBytes for a string
+func HashString(s string) string {
+	return HashBytes([]byte(s))
+}
+
+func (p *plan) toMap(v reflect.Value, path walkPath) (map[string]any, error) {
+	m := make(map[string]any, len(p.fields))
+	for _, f := range p.fields {
+		value, err := f.redactValue(v.Field(f.index), path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+		m[f.name] = value
+	}
+	return m, nil
+}
+
+// ToMap returns the safe fields of the struct v, or of the struct v points to, by field name.
+// Masked and hashed fields are strings, nested structs with safe tags are maps themselves,
+// and so are the ones pointed to or held by the slices, arrays and maps of a field, which become slices of any and maps of any.
+func ToMap(v any) (map[string]any, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := planOf(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	return p.toMap(rv, walkPath{})
+}
@@ -0,0 +1,225 @@
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type address struct {
+	City   string
+	Street string `safe:"mask"`
+}
+
+type user struct {
+	Name     string
+	Email    string `safe:"mask"`
+	Password string `safe:"omit"`
+	Token    []byte `safe:"hash"`
+	Age      int    `safe:"hash"`
+	Home     address
+	Tags     []string
+	internal string
+}
+
+func sha(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func testUser() user {
+	return user{
+		Name:     "Ada",
+		Email:    "ada@example.com",
+		Password: "hunter2",
+		Token:    []byte("token"),
+		Age:      36,
+		Home:     address{City: "London", Street: "Baker Street"},
+		Tags:     []string{"admin"},
+		internal: "internal",
+	}
+}
+
+func TestToMap(t *testing.T) {
+	expected := map[string]any{
+		"Name":  "Ada",
+		"Email": Mask,
+		"Token": sha("token"),
+		"Age":   sha("36"),
+		"Home":  map[string]any{"City": "London", "Street": Mask},
+		"Tags":  []string{"admin"},
+	}
+
+	u := testUser()
+	for _, v := range []any{u, &u} {
+		actual, err := ToMap(v)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("actual %v expected %v", actual, expected)
+		}
+	}
+}
+
+func TestToMapErrors(t *testing.T) {
+	var nilUser *user
+	for _, v := range []any{42, "user", nilUser, nil} {
+		if _, err := ToMap(v); !errors.Is(err, ErrNotStruct) {
+			t.Errorf("%#v: expected %v got %v", v, ErrNotStruct, err)
+		}
+	}
+
+	type invalid struct {
+		Secret string `safe:"encrypt"`
+	}
+	if _, err := ToMap(invalid{}); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("expected %v got %v", ErrInvalidTag, err)
+	}
+}
+
+// A self referencing type must not build its plan forever
+type node struct {
+	Value  string `safe:"mask"`
+	Parent *node
+}
+
+func TestToMapRecursiveType(t *testing.T) {
+	actual, err := ToMap(node{Value: "child", Parent: &node{Value: "parent"}})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	expected := map[string]any{"Value": Mask, "Parent": map[string]any{"Value": Mask, "Parent": nil}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("actual %v expected %v", actual, expected)
+	}
+
+	cyclic := &node{Value: "cyclic"}
+	cyclic.Parent = cyclic
+	if _, err := ToMap(cyclic); !errors.Is(err, ErrCycle) {
+		t.Errorf("expected %v got %v", ErrCycle, err)
+	}
+}
+
+func TestToMapContainers(t *testing.T) {
+	type person struct {
+		Name     string
+		Home     *address
+		Previous []address
+		Work     [1]address
+		Holidays map[string]*address
+		Friends  []*person
+		Nicks    []string
+	}
+
+	home := address{City: "London", Street: "Baker Street"}
+	masked := map[string]any{"City": "London", "Street": Mask}
+	p := person{
+		Name:     "Ada",
+		Home:     &home,
+		Previous: []address{home, home},
+		Work:     [1]address{home},
+		Holidays: map[string]*address{"summer": &home, "winter": nil},
+		Friends:  []*person{{Name: "Charles"}},
+		Nicks:    []string{"Countess"},
+	}
+
+	actual, err := ToMap(p)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	expected := map[string]any{
+		"Name":     "Ada",
+		"Home":     masked,
+		"Previous": []any{masked, masked},
+		"Work":     []any{masked},
+		"Holidays": map[string]any{"summer": masked, "winter": nil},
+		"Friends": []any{map[string]any{
+			"Name": "Charles", "Home": nil, "Previous": nil, "Work": []any{map[string]any{"City": "", "Street": Mask}},
+			"Holidays": nil, "Friends": nil, "Nicks": []string(nil),
+		}},
+		"Nicks": []string{"Countess"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("actual %v\nexpected %v", actual, expected)
+	}
+
+	var dst struct{ Previous []address }
+	if err := Into(&dst, p); !errors.Is(err, ErrIncompatibleField) {
+		t.Errorf("a slice of structs with sensitive fields: expected %v got %v", ErrIncompatibleField, err)
+	}
+}
+
+func TestInto(t *testing.T) {
+	type safeUser struct {
+		Name     string
+		Email    string
+		Password string // omitted in user, so never copied
+		Token    string
+		Tags     []string
+		Extra    int
+	}
+
+	dst := safeUser{Password: "untouched", Extra: 7}
+	if err := Into(&dst, testUser()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := safeUser{Name: "Ada", Email: Mask, Password: "untouched", Token: sha("token"), Tags: []string{"admin"}, Extra: 7}
+	if !reflect.DeepEqual(dst, expected) {
+		t.Errorf("actual %+v expected %+v", dst, expected)
+	}
+}
+
+func TestIntoErrors(t *testing.T) {
+	u := testUser()
+
+	if err := Into(struct{ Name string }{}, u); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("a destination which is not a pointer: expected %v got %v", ErrNotStruct, err)
+	}
+
+	var maskedInt struct{ Email int }
+	if err := Into(&maskedInt, u); !errors.Is(err, ErrIncompatibleField) {
+		t.Errorf("a masked field into an int: expected %v got %v", ErrIncompatibleField, err)
+	}
+
+	var wrongType struct{ Name []byte }
+	if err := Into(&wrongType, u); !errors.Is(err, ErrIncompatibleField) {
+		t.Errorf("a string into a byte slice: expected %v got %v", ErrIncompatibleField, err)
+	}
+
+	var nested struct{ Home address }
+	if err := Into(&nested, u); !errors.Is(err, ErrIncompatibleField) {
+		t.Errorf("a nested struct with sensitive fields: expected %v got %v", ErrIncompatibleField, err)
+	}
+}
+
+func TestConcurrentPlans(t *testing.T) {
+	type safeUser struct{ Name, Email string }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var dst safeUser
+			if err := Into(&dst, testUser()); err != nil || dst.Email != Mask {
+				t.Errorf("unexpected result %+v %v", dst, err)
+			}
+			if _, err := ToMap(testUser()); err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkToMap(b *testing.B) {
+	u := testUser()
+	for i := 0; i < b.N; i++ {
+		_, _ = ToMap(&u)
+	}
+}
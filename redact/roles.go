@@ -0,0 +1,151 @@
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	// ErrUnknownRole is returned by View for a role without any visible field
+	ErrUnknownRole = errors.New("redact: unknown role")
+	// ErrUnknownField is returned by View when a role allows a field the struct doesn't have
+	ErrUnknownField = errors.New("redact: unknown field")
+)
+
+// Role names a consumer of the data, such as an administrator or the public
+type Role string
+
+// RoleViews maps every role to the fields it may see. Fields are hidden unless a role allows them,
+// whatever their safe tags, so that a privileged role can be shown the sensitive data.
+type RoleViews struct {
+	mu    sync.RWMutex
+	roles map[Role]map[string]Policy
+	views map[viewKey][]viewField // resolved field indexes, cleared on every change
+}
+
+type viewKey struct {
+	role Role
+	typ  reflect.Type
+}
+
+type viewField struct {
+	index  int
+	name   string
+	policy Policy
+}
+
+// NewRoleViews returns the views where each role sees the given fields as they are
+func NewRoleViews(roles map[Role][]string) *RoleViews {
+	r := &RoleViews{roles: make(map[Role]map[string]Policy), views: make(map[viewKey][]viewField)}
+	for role, fields := range roles {
+		r.roles[role] = make(map[string]Policy, len(fields))
+		for _, field := range fields {
+			r.roles[role][field] = Keep
+		}
+	}
+	return r
+}
+
+// Allow shows a field to a role, redacted with the policy. Omit hides it again.
+func (r *RoleViews) Allow(role Role, field string, policy Policy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if policy == Omit {
+		delete(r.roles[role], field)
+	} else {
+		if r.roles[role] == nil {
+			r.roles[role] = make(map[string]Policy)
+		}
+		r.roles[role][field] = policy
+	}
+	r.views = make(map[viewKey][]viewField)
+}
+
+// Fields returns the policy of every field visible to the role
+func (r *RoleViews) Fields(role Role) map[string]Policy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fields := make(map[string]Policy, len(r.roles[role]))
+	for name, policy := range r.roles[role] {
+		fields[name] = policy
+	}
+	return fields
+}
+
+// View returns the fields of the struct v visible to the role, by Go field name.
+// Kept fields hold their value, kept slices being copied so that the view doesn't share memory with v,
+// the other policies give strings as in MarshalJSON.
+func (r *RoleViews) View(role Role, v any) (map[string]any, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := r.viewOf(role, rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	view := make(map[string]any, len(fields))
+	for _, f := range fields {
+		value := rv.Field(f.index)
+		if f.policy == Keep {
+			view[f.name] = keptValue(value)
+		} else {
+			view[f.name] = f.policy.apply(value)
+		}
+	}
+	return view, nil
+}
+
+// keptValue returns the value of a kept field, copying a slice
+func keptValue(value reflect.Value) any {
+	if value.Kind() != reflect.Slice || value.IsNil() {
+		return value.Interface()
+	}
+	copied := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+	reflect.Copy(copied, value)
+	return copied.Interface()
+}
+
+// viewOf resolves the fields of a role on a struct type once
+func (r *RoleViews) viewOf(role Role, t reflect.Type) ([]viewField, error) {
+	key := viewKey{role, t}
+
+	r.mu.RLock()
+	fields, ok := r.views[key]
+	r.mu.RUnlock()
+	if ok {
+		return fields, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	allowed, ok := r.roles[role]
+	if !ok || len(allowed) == 0 {
+		return nil, fmt.Errorf("%w %q", ErrUnknownRole, role)
+	}
+
+	fields = make([]viewField, 0, len(allowed))
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if policy, ok := allowed[sf.Name]; ok && sf.IsExported() {
+			fields = append(fields, viewField{index: i, name: sf.Name, policy: policy})
+		}
+	}
+	if len(fields) != len(allowed) {
+		for name := range allowed {
+			if sf, ok := t.FieldByName(name); !ok || len(sf.Index) != 1 || !sf.IsExported() {
+				return nil, fmt.Errorf("%w %s.%s for role %q", ErrUnknownField, t, name, role)
+			}
+		}
+	}
+
+	r.views[key] = fields
+	return fields, nil
+}
@@ -0,0 +1,101 @@
+package redact
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestRoleViews(t *testing.T) {
+	views := NewRoleViews(map[Role][]string{
+		"admin":  {"Owner", "Card", "PIN", "Email", "Token"},
+		"public": {"Owner"},
+	})
+	a := testAccount()
+
+	tests := []struct {
+		role     Role
+		expected map[string]any
+	}{
+		// Roles ignore the safe tags, the admin sees the omitted PIN and the masked Email
+		{"admin", map[string]any{"Owner": "Ada Lovelace", "Card": "4970101234567890", "PIN": 1234, "Email": "ada@example.com", "Token": []byte("token")}},
+		{"public", map[string]any{"Owner": "Ada Lovelace"}},
+	}
+	for _, test := range tests {
+		view, err := views.View(test.role, &a)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", test.role, err)
+		}
+		if !reflect.DeepEqual(view, test.expected) {
+			t.Errorf("%s: actual %v expected %v", test.role, view, test.expected)
+		}
+	}
+
+	if view, _ := views.View("admin", &a); view != nil {
+		view["Token"].([]byte)[0] = 'X'
+		if string(a.Token) != "token" {
+			t.Errorf("changing the admin view changed the Token to %s", a.Token)
+		}
+	}
+
+	views.Allow("public", "Card", MaskLast4)
+	views.Allow("support", "Email", Truncate(3))
+	views.Allow("admin", "Token", Omit)
+
+	if view, _ := views.View("public", a); !reflect.DeepEqual(view, map[string]any{"Owner": "Ada Lovelace", "Card": "****7890"}) {
+		t.Errorf("public view after Allow: %v", view)
+	}
+	if view, _ := views.View("support", a); !reflect.DeepEqual(view, map[string]any{"Email": "ada…"}) {
+		t.Errorf("support view: %v", view)
+	}
+	if view, _ := views.View("admin", a); len(view) != 4 || view["Token"] != nil {
+		t.Errorf("admin view after omitting Token: %v", view)
+	}
+	if fields := views.Fields("public"); !reflect.DeepEqual(fields, map[string]Policy{"Owner": Keep, "Card": MaskLast4}) {
+		t.Errorf("public fields %v", fields)
+	}
+}
+
+func TestRoleViewsErrors(t *testing.T) {
+	views := NewRoleViews(map[Role][]string{"public": {"Owner"}, "typo": {"Ownr"}, "empty": {}})
+	a := testAccount()
+
+	for _, role := range []Role{"guest", "empty"} {
+		if _, err := views.View(role, a); !errors.Is(err, ErrUnknownRole) {
+			t.Errorf("%s: expected %v got %v", role, ErrUnknownRole, err)
+		}
+	}
+	if _, err := views.View("typo", a); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("expected %v got %v", ErrUnknownField, err)
+	}
+	if _, err := views.View("public", 42); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("expected %v got %v", ErrNotStruct, err)
+	}
+}
+
+func TestRoleViewsConcurrentChanges(t *testing.T) {
+	views := NewRoleViews(map[Role][]string{"public": {"Owner"}})
+	a := testAccount()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if view, err := views.View("public", a); err != nil || view["Owner"] != "Ada Lovelace" {
+					t.Errorf("unexpected view %v %v", view, err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				views.Allow("public", "Card", MaskAll)
+				views.Allow("public", "Card", Omit)
+			}
+		}()
+	}
+	wg.Wait()
+}